@@ -0,0 +1,361 @@
+package tsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient/utils/jsonutil"
+)
+
+// ErrBulkIndexerClosed is returned by (*BulkIndexer).Add if the indexer has already been closed.
+const ErrBulkIndexerClosed = errors.Sentinel("bulk indexer is closed")
+
+// BulkIndexerConfig is the configuration for a BulkIndexer.
+// All fields are optional.
+type BulkIndexerConfig struct {
+	// Number of worker goroutines sending batches to Typesense.
+	// Default: 2
+	Workers int
+
+	// A batch is flushed once it contains this many documents.
+	// Default: 1000
+	FlushDocuments int
+	// A batch is flushed once its encoded size reaches this many bytes.
+	// Default: 5 MiB
+	FlushBytes int
+	// All pending batches are flushed at this interval. Set to a negative value to disable.
+	// Default: 5 seconds
+	FlushInterval time.Duration
+
+	// Maximum number of times a batch is retried if Typesense returns 503 Service Unavailable.
+	// Adding documents blocks while batches are being retried. Set to a negative value to disable retries.
+	// Once Close is called or the client's context is done, batches are no longer retried,
+	// and batches waiting to be retried fail with the last error.
+	// The client's own MaxRetries is not used for imports made by the indexer.
+	// Default: 5
+	MaxRetries int
+	// Time to wait before retrying a batch, doubled after every attempt.
+	// Default: 500 milliseconds
+	RetryBackoff time.Duration
+
+	// OnFailure is called for every document that could not be imported.
+	// If Typesense rejected the document, err is an *ImportError.
+	// It is called from the worker goroutines, so it must be safe for concurrent use.
+	OnFailure func(item BulkIndexerItem, err error)
+}
+
+// BulkIndexerItem is a single document added to a BulkIndexer.
+type BulkIndexerItem struct {
+	Collection string
+	Action     string
+
+	// The JSON encoded document.
+	Document jsonutil.Raw
+}
+
+// BulkIndexerStats are statistics for a BulkIndexer.
+type BulkIndexerStats struct {
+	// Number of documents added
+	Added uint64
+	// Number of documents successfully imported
+	Indexed uint64
+	// Number of documents that failed to import
+	Failed uint64
+	// Number of import requests made, including retries
+	Requests uint64
+	// Number of times a batch was retried
+	Retries uint64
+}
+
+// BulkIndexer imports documents in batches using multiple goroutines.
+// It is safe for concurrent use.
+type BulkIndexer struct {
+	// first for 64-bit alignment of atomic operations
+	stats BulkIndexerStats
+
+	c   *Client
+	cfg BulkIndexerConfig
+
+	mu      sync.Mutex
+	batches map[bulkKey]*bulkBatch
+	closed  bool
+	// pending tracks batches taken out of the map but not yet sent to queue
+	pending sync.WaitGroup
+
+	queue   chan *bulkBatch
+	workers sync.WaitGroup
+
+	stop       chan struct{}
+	tickerDone chan struct{}
+}
+
+type bulkKey struct {
+	collection, action string
+}
+
+type bulkBatch struct {
+	bulkKey
+	buf   bytes.Buffer
+	items []BulkIndexerItem
+}
+
+// NewBulkIndexer creates a new BulkIndexer and starts its worker goroutines.
+// Close must be called to flush remaining documents and stop the workers.
+func (c *Client) NewBulkIndexer(cfg BulkIndexerConfig) *BulkIndexer {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.FlushDocuments <= 0 {
+		cfg.FlushDocuments = 1000
+	}
+	if cfg.FlushBytes <= 0 {
+		cfg.FlushBytes = 5 * 1024 * 1024
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	// batches are retried by the indexer, not the client: imports aren't idempotent, so the client never retries them
+	b := &BulkIndexer{
+		c:          c,
+		cfg:        cfg,
+		batches:    map[bulkKey]*bulkBatch{},
+		queue:      make(chan *bulkBatch, cfg.Workers),
+		stop:       make(chan struct{}),
+		tickerDone: make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		b.workers.Add(1)
+		go b.worker()
+	}
+
+	if cfg.FlushInterval > 0 {
+		go b.ticker()
+	} else {
+		close(b.tickerDone)
+	}
+
+	return b
+}
+
+// Add adds a document to the indexer. action is optional and may be left empty.
+// Add blocks if all workers are busy and the queue is full.
+func (b *BulkIndexer) Add(collection, action string, doc interface{}) error {
+	if collection == "" {
+		return ErrEmptyCollection
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	key := bulkKey{collection, action}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBulkIndexerClosed
+	}
+
+	batch, ok := b.batches[key]
+	if !ok {
+		batch = &bulkBatch{bulkKey: key}
+		b.batches[key] = batch
+	}
+
+	batch.buf.Write(data)
+	batch.buf.WriteByte('\n')
+	batch.items = append(batch.items, BulkIndexerItem{
+		Collection: collection,
+		Action:     action,
+		Document:   data,
+	})
+	atomic.AddUint64(&b.stats.Added, 1)
+
+	if len(batch.items) < b.cfg.FlushDocuments && batch.buf.Len() < b.cfg.FlushBytes {
+		b.mu.Unlock()
+		return nil
+	}
+
+	delete(b.batches, key)
+	b.pending.Add(1)
+	b.mu.Unlock()
+
+	b.queue <- batch
+	b.pending.Done()
+	return nil
+}
+
+// Flush queues all pending documents to be sent, without waiting for them to be imported.
+func (b *BulkIndexer) Flush() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	batches := b.takeAll()
+	b.pending.Add(1)
+	b.mu.Unlock()
+
+	b.send(batches)
+	b.pending.Done()
+}
+
+// Close flushes all pending documents, waits for them to be imported, and stops the workers.
+// Batches that fail with 503 Service Unavailable after Close is called aren't retried, so Close doesn't wait for retry backoffs.
+// Adding documents after calling Close returns ErrBulkIndexerClosed.
+func (b *BulkIndexer) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	batches := b.takeAll()
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.tickerDone
+
+	b.send(batches)
+	b.pending.Wait()
+
+	close(b.queue)
+	b.workers.Wait()
+}
+
+// Stats returns the indexer's statistics.
+func (b *BulkIndexer) Stats() BulkIndexerStats {
+	return BulkIndexerStats{
+		Added:    atomic.LoadUint64(&b.stats.Added),
+		Indexed:  atomic.LoadUint64(&b.stats.Indexed),
+		Failed:   atomic.LoadUint64(&b.stats.Failed),
+		Requests: atomic.LoadUint64(&b.stats.Requests),
+		Retries:  atomic.LoadUint64(&b.stats.Retries),
+	}
+}
+
+// takeAll removes all batches from the map. b.mu must be held.
+func (b *BulkIndexer) takeAll() []*bulkBatch {
+	batches := make([]*bulkBatch, 0, len(b.batches))
+	for k, batch := range b.batches {
+		batches = append(batches, batch)
+		delete(b.batches, k)
+	}
+	return batches
+}
+
+func (b *BulkIndexer) send(batches []*bulkBatch) {
+	for _, batch := range batches {
+		b.queue <- batch
+	}
+}
+
+func (b *BulkIndexer) ticker() {
+	defer close(b.tickerDone)
+
+	t := time.NewTicker(b.cfg.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-t.C:
+			b.Flush()
+		}
+	}
+}
+
+func (b *BulkIndexer) worker() {
+	defer b.workers.Done()
+
+	for batch := range b.queue {
+		b.flush(batch)
+	}
+}
+
+func (b *BulkIndexer) flush(batch *bulkBatch) {
+	body := batch.buf.Bytes()
+	backoff := b.cfg.RetryBackoff
+
+	var (
+		res []importResponse
+		err error
+	)
+	for attempt := 0; ; attempt++ {
+		atomic.AddUint64(&b.stats.Requests, 1)
+
+		res, err = b.c.importJSONL(batch.collection, batch.action, bytes.NewReader(body))
+		if !errors.Is(err, ErrUnavailable) || attempt >= b.cfg.MaxRetries {
+			break
+		}
+
+		b.c.log().Warn("bulk import unavailable, retrying",
+			"collection", batch.collection, "documents", len(batch.items), "attempt", attempt+1, "backoff", backoff)
+		if !b.wait(backoff) {
+			break
+		}
+		atomic.AddUint64(&b.stats.Retries, 1)
+		backoff *= 2
+	}
+
+	if err != nil {
+		atomic.AddUint64(&b.stats.Failed, uint64(len(batch.items)))
+		for _, item := range batch.items {
+			b.fail(item, err)
+		}
+		return
+	}
+
+	for i, item := range batch.items {
+		if i < len(res) && res[i].Success {
+			atomic.AddUint64(&b.stats.Indexed, 1)
+			continue
+		}
+
+		atomic.AddUint64(&b.stats.Failed, 1)
+		if i >= len(res) {
+			b.fail(item, errors.New("no import result returned for document"))
+			continue
+		}
+		b.fail(item, &ImportError{
+			Message:  res[i].Error,
+			Document: res[i].Document,
+		})
+	}
+}
+
+// wait waits for d before retrying a batch.
+// It returns false without waiting the full duration if the indexer is closed or the client's context is done.
+func (b *BulkIndexer) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-b.stop:
+	case <-b.c.context().Done():
+	}
+	return false
+}
+
+func (b *BulkIndexer) fail(item BulkIndexerItem, err error) {
+	if b.cfg.OnFailure != nil {
+		b.cfg.OnFailure(item, err)
+	}
+}
@@ -0,0 +1,220 @@
+package tsclient_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+type bulkDoc struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestBulkIndexerClose(t *testing.T) {
+	c, _ := tstest.New(t)
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []string
+	)
+	b := c.NewBulkIndexer(tsclient.BulkIndexerConfig{
+		Workers:        4,
+		FlushDocuments: 50,
+		// only Close flushes the last partial batches
+		FlushInterval: -1,
+		OnFailure: func(item tsclient.BulkIndexerItem, err error) {
+			var ierr *tsclient.ImportError
+			if !errors.As(err, &ierr) {
+				t.Errorf("failure is not an *ImportError: %v", err)
+			}
+
+			mu.Lock()
+			failed = append(failed, string(item.Document))
+			mu.Unlock()
+		},
+	})
+
+	const goroutines, perGoroutine = 8, 130
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				id := strconv.Itoa(g*perGoroutine + i)
+				err := b.Add("docs", "create", bulkDoc{ID: id, Name: "doc " + id})
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// a duplicate ID is rejected by the server
+	err = b.Add("docs", "create", bulkDoc{ID: "0", Name: "duplicate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Close()
+
+	const total = goroutines * perGoroutine
+	stats := b.Stats()
+	if stats.Added != total+1 || stats.Indexed != total || stats.Failed != 1 {
+		t.Errorf("unexpected stats after Close: %+v", stats)
+	}
+	if len(failed) != 1 || failed[0] != `{"id":"0","name":"duplicate"}` {
+		t.Errorf("unexpected failures: %v", failed)
+	}
+
+	res, err := c.Search("docs", tsclient.SearchData{Query: "*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found != total {
+		t.Errorf("collection has %v documents after Close, want %v", res.Found, total)
+	}
+
+	err = b.Add("docs", "create", bulkDoc{ID: "new"})
+	if !errors.Is(err, tsclient.ErrBulkIndexerClosed) {
+		t.Errorf("Add after Close: got %v, want ErrBulkIndexerClosed", err)
+	}
+}
+
+// importServer returns a server responding to imports with 503 Service Unavailable while unavailable returns true,
+// and importing every document successfully otherwise.
+func importServer(t *testing.T, unavailable func(n int32) bool) (srv *httptest.Server, requests *int32) {
+	t.Helper()
+
+	var count int32
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable(atomic.AddInt32(&count, 1)) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"message": "Not Ready or Lagging"}`)
+			return
+		}
+
+		s := bufio.NewScanner(r.Body)
+		for s.Scan() {
+			_, _ = io.WriteString(w, `{"success": true}`+"\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &count
+}
+
+func TestBulkIndexerBackpressure(t *testing.T) {
+	srv, requests := importServer(t, func(n int32) bool { return n <= 2 })
+
+	c := tsclient.NewLazy(srv.URL, "key")
+	// the indexer does its own retries, so these must not multiply them
+	c.MaxRetries = 3
+	c.RetryBackoff = time.Millisecond
+
+	b := c.NewBulkIndexer(tsclient.BulkIndexerConfig{
+		Workers:        1,
+		FlushDocuments: 10,
+		FlushInterval:  -1,
+		RetryBackoff:   5 * time.Millisecond,
+		OnFailure: func(item tsclient.BulkIndexerItem, err error) {
+			t.Errorf("document %s failed: %v", item.Document, err)
+		},
+	})
+
+	for i := 0; i < 10; i++ {
+		err := b.Add("docs", "", bulkDoc{ID: strconv.Itoa(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// batches aren't retried after Close, so wait for the batch to go through first
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Stats().Indexed+b.Stats().Failed < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("batch was not imported: %+v", b.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+
+	stats := b.Stats()
+	if stats.Indexed != 10 || stats.Requests != 3 || stats.Retries != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("server got %v requests, want 3", got)
+	}
+}
+
+func TestBulkIndexerCloseDuringBackoff(t *testing.T) {
+	srv, requests := importServer(t, func(int32) bool { return true })
+
+	c := tsclient.NewLazy(srv.URL, "key")
+
+	var failures int32
+	b := c.NewBulkIndexer(tsclient.BulkIndexerConfig{
+		FlushDocuments: 1,
+		FlushInterval:  -1,
+		RetryBackoff:   time.Hour,
+		OnFailure: func(item tsclient.BulkIndexerItem, err error) {
+			if !errors.Is(err, tsclient.ErrUnavailable) {
+				t.Errorf("got %v, want ErrUnavailable", err)
+			}
+			atomic.AddInt32(&failures, 1)
+		},
+	})
+
+	err := b.Add("docs", "", bulkDoc{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait until the batch is waiting to be retried
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(requests) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch was never sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close is waiting for the retry backoff")
+	}
+
+	if got := atomic.LoadInt32(&failures); got != 1 {
+		t.Errorf("OnFailure called %v times, want 1", got)
+	}
+	if stats := b.Stats(); stats.Retries != 0 || stats.Failed != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,168 @@
+package tsclient
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheStats are statistics for the client-side search cache.
+type CacheStats struct {
+	// Number of searches served from the cache
+	Hits uint64
+	// Number of searches not found in the cache, or found but expired or invalidated
+	Misses uint64
+	// Number of entries removed to make room for new ones
+	Evictions uint64
+	// Number of times a collection's entries were invalidated by a write
+	Invalidations uint64
+	// Number of entries currently in the cache
+	Size int
+}
+
+// EnableSearchCache enables a client-side cache of up to size search results, each kept for ttl.
+// The least recently used results are evicted first.
+//
+// Results are keyed by collection and the encoded search parameters.
+// All cached results for a collection are invalidated when this client writes to it,
+// but writes made by other clients are only picked up once the entries expire.
+//
+// EnableSearchCache is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) EnableSearchCache(size int, ttl time.Duration) {
+	c.cache = &searchCache{
+		size:        size,
+		ttl:         ttl,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		generations: map[string]uint64{},
+	}
+}
+
+// SearchCacheStats returns statistics for the search cache. It returns the zero value if the cache isn't enabled.
+func (c *Client) SearchCacheStats() CacheStats {
+	return c.cache.stats()
+}
+
+type searchCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// incremented every time a collection is written to
+	generations map[string]uint64
+
+	st CacheStats
+}
+
+type cacheEntry struct {
+	key        string
+	collection string
+	generation uint64
+	expires    time.Time
+	// the raw response, so every hit gets its own copy of the result
+	body []byte
+}
+
+// get returns the cached response for key, if any.
+func (sc *searchCache) get(collection, key string) ([]byte, bool) {
+	if sc == nil {
+		return nil, false
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	el, ok := sc.entries[key]
+	if !ok {
+		sc.st.Misses++
+		return nil, false
+	}
+
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expires) || e.generation != sc.generations[collection] {
+		sc.lru.Remove(el)
+		delete(sc.entries, key)
+		sc.st.Misses++
+		return nil, false
+	}
+
+	sc.lru.MoveToFront(el)
+	sc.st.Hits++
+	return e.body, true
+}
+
+// generation returns the collection's current generation, to be passed to put.
+func (sc *searchCache) generation(collection string) uint64 {
+	if sc == nil {
+		return 0
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.generations[collection]
+}
+
+// put adds a response to the cache.
+// If the collection was written to since generation was called, the response is stale and isn't cached.
+func (sc *searchCache) put(collection, key string, generation uint64, body []byte) {
+	if sc == nil || sc.size <= 0 {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if generation != sc.generations[collection] {
+		return
+	}
+
+	e := &cacheEntry{
+		key:        key,
+		collection: collection,
+		generation: generation,
+		expires:    time.Now().Add(sc.ttl),
+		body:       body,
+	}
+
+	if el, ok := sc.entries[key]; ok {
+		el.Value = e
+		sc.lru.MoveToFront(el)
+		return
+	}
+
+	sc.entries[key] = sc.lru.PushFront(e)
+
+	for sc.lru.Len() > sc.size {
+		el := sc.lru.Back()
+		sc.lru.Remove(el)
+		delete(sc.entries, el.Value.(*cacheEntry).key)
+		sc.st.Evictions++
+	}
+}
+
+// invalidate marks all cached results for the collection as stale.
+func (sc *searchCache) invalidate(collection string) {
+	if sc == nil {
+		return
+	}
+
+	sc.mu.Lock()
+	sc.generations[collection]++
+	sc.st.Invalidations++
+	sc.mu.Unlock()
+}
+
+func (sc *searchCache) stats() CacheStats {
+	if sc == nil {
+		return CacheStats{}
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	st := sc.st
+	st.Size = sc.lru.Len()
+	return st
+}
@@ -0,0 +1,107 @@
+package tsclient_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+func TestSearchCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *tsclient.Client, collection string) error
+		// the number of hits for "plural" after the write
+		wantFound int
+	}{
+		{"Insert", func(c *tsclient.Client, collection string) error {
+			return c.Insert(collection, bulkDoc{ID: "3", Name: "plural"}, nil)
+		}, 3},
+		{"Upsert", func(c *tsclient.Client, collection string) error {
+			return c.Upsert(collection, bulkDoc{ID: "1", Name: "system"}, nil)
+		}, 1},
+		{"Import", func(c *tsclient.Client, collection string) error {
+			_, err := c.Import(collection, "upsert", []bulkDoc{{ID: "3", Name: "plural"}})
+			return err
+		}, 3},
+		{"UpdateDocument", func(c *tsclient.Client, collection string) error {
+			return c.UpdateDocument(collection, "1", map[string]string{"name": "system"}, nil)
+		}, 1},
+		{"UpdateQuery", func(c *tsclient.Client, collection string) error {
+			_, err := c.UpdateQuery(collection, "id:=1", map[string]string{"name": "system"})
+			return err
+		}, 1},
+		{"DeleteDocument", func(c *tsclient.Client, collection string) error {
+			return c.DeleteDocument(collection, "1", nil)
+		}, 1},
+		{"DeleteQuery", func(c *tsclient.Client, collection string) error {
+			_, err := c.DeleteQuery(collection, "id:=1", 0)
+			return err
+		}, 1},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := tstest.New(t)
+			c.EnableSearchCache(10, time.Hour)
+
+			for _, name := range []string{"docs", "other"} {
+				_, err := c.CreateCollection(name, "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = c.Import(name, "create", []bulkDoc{{ID: "1", Name: "plural"}, {ID: "2", Name: "plural"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var searches int32
+			c.Use(tsclient.Hooks(func(info *tsclient.RequestInfo) {
+				if info.Endpoint == "/collections/docs/documents/search" {
+					atomic.AddInt32(&searches, 1)
+				}
+			}, nil))
+
+			search := func(wantFound int, wantSearches int32) {
+				t.Helper()
+
+				res, err := c.Search("docs", tsclient.SearchData{Query: "plural", QueryBy: []string{"name"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Found != wantFound {
+					t.Errorf("got %v hits, want %v", res.Found, wantFound)
+				}
+				if n := atomic.LoadInt32(&searches); n != wantSearches {
+					t.Errorf("made %v searches, want %v", n, wantSearches)
+				}
+			}
+
+			search(2, 1)
+			search(2, 1)
+
+			// writes to other collections don't invalidate the results
+			err := test.write(c, "other")
+			if err != nil {
+				t.Fatal(err)
+			}
+			search(2, 1)
+
+			err = test.write(c, "docs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			search(test.wantFound, 2)
+			search(test.wantFound, 2)
+
+			st := c.SearchCacheStats()
+			if st.Hits != 3 || st.Misses != 2 || st.Size != 1 {
+				t.Errorf("got stats %+v, want 3 hits, 2 misses and 1 entry", st)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/termora/tsclient"
+)
+
+var collectionCommands = &command{sub: map[string]*command{
+	"list": {usage: "collections list", help: "list all collections", run: runCollectionsList},
+	"get":  {usage: "collections get <name>", help: "show a collection's schema", run: runCollectionsGet},
+	"create": {
+		usage: "collections create [-sort field] [-schema file.json] <name> [field:type[:facet][:noindex][:infix]...]",
+		help:  "create a collection from field arguments or a JSON schema file",
+		run:   runCollectionsCreate,
+	},
+	"drop": {usage: "collections drop [-yes] <name>", help: "permanently drop a collection", run: runCollectionsDrop},
+}}
+
+func runCollectionsList(a *app, args []string) error {
+	err := nargs(args, 0, 0, "collections list")
+	if err != nil {
+		return err
+	}
+
+	cols, err := a.c.Collections()
+	if err = a.check(err); err != nil {
+		return err
+	}
+
+	res := result{value: cols, columns: []string{"NAME", "DOCUMENTS", "FIELDS", "DEFAULT SORTING FIELD"}}
+	for _, col := range cols {
+		res.rows = append(res.rows, []string{col.Name, strconv.Itoa(col.NumDocuments), strconv.Itoa(len(col.Fields)), col.DefaultSortingField})
+	}
+	return a.print(res)
+}
+
+func runCollectionsGet(a *app, args []string) error {
+	err := nargs(args, 1, 1, "collections get <name>")
+	if err != nil {
+		return err
+	}
+
+	col, err := a.c.Collection(args[0])
+	if err = a.check(err); err != nil {
+		return err
+	}
+	return a.print(fieldTable(col))
+}
+
+// fieldTable returns a result printing a collection's fields as a table.
+func fieldTable(col tsclient.Collection) result {
+	res := result{value: col, columns: []string{"FIELD", "TYPE", "FACET", "INDEX", "INFIX", "EMBED"}}
+	for _, f := range col.Fields {
+		embed := ""
+		if f.Embed != nil {
+			embed = f.Embed.ModelConfig.ModelName + " from " + strings.Join(f.Embed.From, ",")
+		}
+
+		res.rows = append(res.rows, []string{
+			f.Name, f.Type, strconv.FormatBool(f.Facet), strconv.FormatBool(f.Index), strconv.FormatBool(f.Infix), embed,
+		})
+	}
+	return res
+}
+
+func runCollectionsCreate(a *app, args []string) error {
+	const usage = "collections create [-sort field] [-schema file.json] <name> [field:type[:facet][:noindex][:infix]...]"
+
+	fs := flags(usage)
+	sort := fs.String("sort", "", "default sorting field")
+	schema := fs.String("schema", "", "JSON file with the collection schema, in the format returned by collections get -o json")
+	_ = fs.Parse(args)
+	args = fs.Args()
+
+	var (
+		name   string
+		fields []tsclient.CreateFieldData
+	)
+
+	if *schema != "" {
+		b, err := os.ReadFile(*schema)
+		if err != nil {
+			return err
+		}
+
+		var col tsclient.Collection
+		err = json.Unmarshal(b, &col)
+		if err != nil {
+			return fmt.Errorf("reading %v: %w", *schema, err)
+		}
+
+		name = col.Name
+		if *sort == "" {
+			*sort = col.DefaultSortingField
+		}
+		for _, f := range col.Fields {
+			fields = append(fields, tsclient.CreateFieldData{
+				Name:    f.Name,
+				Type:    f.Type,
+				Facet:   f.Facet,
+				NoIndex: !f.Index,
+				Infix:   f.Infix,
+				NumDim:  f.NumDim,
+				Embed:   f.Embed,
+			})
+		}
+	}
+
+	if len(args) > 0 {
+		name = args[0]
+		args = args[1:]
+	}
+	if name == "" || (len(fields) == 0 && len(args) == 0) {
+		return fmt.Errorf("usage: tsctl %v", usage)
+	}
+
+	for _, arg := range args {
+		f, err := parseField(arg)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, f)
+	}
+
+	col, err := a.c.CreateCollection(name, *sort, fields)
+	if err = a.check(err); err != nil {
+		return err
+	}
+	return a.print(fieldTable(col))
+}
+
+// parseField parses a field argument in the format name:type[:facet][:noindex][:infix].
+func parseField(s string) (f tsclient.CreateFieldData, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return f, fmt.Errorf("invalid field %q, expected name:type[:facet][:noindex][:infix]", s)
+	}
+
+	f.Name, f.Type = parts[0], parts[1]
+	for _, opt := range parts[2:] {
+		switch opt {
+		case "facet":
+			f.Facet = true
+		case "noindex":
+			f.NoIndex = true
+		case "infix":
+			f.Infix = true
+		default:
+			return f, fmt.Errorf("invalid option %q for field %q", opt, f.Name)
+		}
+	}
+	return f, nil
+}
+
+func runCollectionsDrop(a *app, args []string) error {
+	const usage = "collections drop [-yes] <name>"
+
+	fs := flags(usage)
+	yes := fs.Bool("yes", false, "don't ask for confirmation")
+	_ = fs.Parse(args)
+
+	err := nargs(fs.Args(), 1, 1, usage)
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	if !*yes && !confirm(fmt.Sprintf("Permanently drop collection %q and all its documents?", name)) {
+		return fmt.Errorf("aborted")
+	}
+
+	col, err := a.c.DeleteCollection(name)
+	if err = a.check(err); err != nil {
+		return err
+	}
+
+	return a.print(result{
+		value:   col,
+		columns: []string{"DROPPED", "DOCUMENTS"},
+		rows:    [][]string{{col.Name, strconv.Itoa(col.NumDocuments)}},
+	})
+}
+
+// confirm asks the user a yes/no question on stderr.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%v [y/N] ", question)
+
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+// config is the connection configuration given as flags.
+//
+// Settings not given as flags are read from TYPESENSE_* environment variables (see tsclient.ConfigFromEnv),
+// and the profile in the config file (see tsclient.LoadConfig for the format).
+// The config file and profile default to TYPESENSE_CONFIG and TYPESENSE_PROFILE,
+// and the file to config.json in $XDG_CONFIG_HOME/tsctl (or the platform's equivalent, see os.UserConfigDir).
+type config struct {
+	URL     string
+	APIKey  string
+	Profile string
+	File    string
+	Timeout time.Duration
+}
+
+// client returns the client configuration, with flags overriding the environment and the config file.
+func (cfg *config) client() (c tsclient.Config, err error) {
+	path, profile := cfg.File, cfg.Profile
+	if path == "" {
+		path = os.Getenv("TYPESENSE_CONFIG")
+	}
+	if profile == "" {
+		profile = os.Getenv("TYPESENSE_PROFILE")
+	}
+	if path == "" {
+		path = defaultConfigFile()
+	}
+
+	if path != "" {
+		c, err = tsclient.LoadConfig(path, profile)
+		if err != nil {
+			return c, err
+		}
+	} else if profile != "" {
+		return c, fmt.Errorf("a profile was given, but there is no config file")
+	}
+
+	err = c.SetFromEnv()
+	if err != nil {
+		return c, err
+	}
+
+	if cfg.URL != "" {
+		c.Nodes = strings.Split(cfg.URL, ",")
+	}
+	if cfg.APIKey != "" {
+		c.APIKey = cfg.APIKey
+	}
+
+	if len(c.Nodes) == 0 {
+		return c, fmt.Errorf("no Typesense URL configured, use -url, TYPESENSE_URL or a profile")
+	}
+	return c, nil
+}
+
+// defaultConfigFile returns the path to the default config file, or an empty string if there is none.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	path := filepath.Join(dir, "tsctl", "config.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
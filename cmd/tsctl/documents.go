@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/termora/tsclient"
+)
+
+var documentCommands = &command{sub: map[string]*command{
+	"get": {usage: "documents get <collection> <id>", help: "show a document", run: runDocumentsGet},
+	"put": {
+		usage: "documents put [-upsert] <collection> [file.json]",
+		help:  "create a document from a JSON file or stdin",
+		run:   runDocumentsPut,
+	},
+	"update": {
+		usage: "documents update <collection> <id> [file.json]",
+		help:  "update a document with the fields in a JSON file or stdin",
+		run:   runDocumentsUpdate,
+	},
+	"delete": {
+		usage: "documents delete [-filter_by filter] [-batch_size n] <collection> [id]",
+		help:  "delete a document by ID, or all documents matching a filter",
+		run:   runDocumentsDelete,
+	},
+}}
+
+func runDocumentsGet(a *app, args []string) error {
+	err := nargs(args, 2, 2, "documents get <collection> <id>")
+	if err != nil {
+		return err
+	}
+
+	var doc json.RawMessage
+	_, err = a.c.Document(args[0], args[1], &doc)
+	if err = a.check(err); err != nil {
+		return err
+	}
+	return a.printDocument(doc)
+}
+
+// printDocument prints a document, as a table of fields and values for table output.
+func (a *app) printDocument(doc json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var m map[string]interface{}
+	err := dec.Decode(&m)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]string, 0, len(m))
+	for k := range m {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	res := result{value: doc, columns: []string{"FIELD", "VALUE"}}
+	for _, f := range fields {
+		res.rows = append(res.rows, []string{f, cell(m[f])})
+	}
+	return a.print(res)
+}
+
+// readInput reads the file named by the optional argument, or stdin if there is none.
+func readInput(args []string) ([]byte, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(args[0])
+}
+
+func runDocumentsPut(a *app, args []string) error {
+	const usage = "documents put [-upsert] <collection> [file.json]"
+
+	fs := flags(usage)
+	upsert := fs.Bool("upsert", false, "replace the document if it already exists")
+	_ = fs.Parse(args)
+
+	err := nargs(fs.Args(), 1, 2, usage)
+	if err != nil {
+		return err
+	}
+
+	b, err := readInput(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	if !json.Valid(b) {
+		return fmt.Errorf("input is not a valid JSON document")
+	}
+
+	var doc json.RawMessage
+	if *upsert {
+		err = a.c.Upsert(fs.Arg(0), json.RawMessage(b), &doc)
+	} else {
+		err = a.c.Insert(fs.Arg(0), json.RawMessage(b), &doc)
+	}
+	if err = a.check(err); err != nil {
+		return err
+	}
+	return a.printDocument(doc)
+}
+
+func runDocumentsUpdate(a *app, args []string) error {
+	err := nargs(args, 2, 3, "documents update <collection> <id> [file.json]")
+	if err != nil {
+		return err
+	}
+
+	b, err := readInput(args[2:])
+	if err != nil {
+		return err
+	}
+	if !json.Valid(b) {
+		return fmt.Errorf("input is not a valid JSON document")
+	}
+
+	var doc json.RawMessage
+	err = a.c.UpdateDocument(args[0], args[1], json.RawMessage(b), &doc)
+	if err = a.check(err); err != nil {
+		return err
+	}
+	return a.printDocument(doc)
+}
+
+func runDocumentsDelete(a *app, args []string) error {
+	const usage = "documents delete [-filter_by filter] [-batch_size n] <collection> [id]"
+
+	fs := flags(usage)
+	filter := fs.String("filter_by", "", "delete all documents matching this filter instead of a single document")
+	batchSize := fs.Int("batch_size", 0, "number of documents deleted at a time when deleting by filter")
+	_ = fs.Parse(args)
+	args = fs.Args()
+
+	if *filter == "" {
+		err := nargs(args, 2, 2, usage)
+		if err != nil {
+			return err
+		}
+
+		var doc json.RawMessage
+		err = a.c.DeleteDocument(args[0], args[1], &doc)
+		if err = a.check(err); err != nil {
+			return err
+		}
+		return a.printDocument(doc)
+	}
+
+	err := nargs(args, 1, 1, usage)
+	if err != nil {
+		return err
+	}
+
+	n, err := a.c.DeleteQuery(args[0], *filter, *batchSize)
+	if err = a.check(err); err != nil {
+		return err
+	}
+
+	return a.print(result{
+		value:   map[string]int{"num_deleted": n},
+		columns: []string{"DELETED"},
+		rows:    [][]string{{strconv.Itoa(n)}},
+	})
+}
+
+func runImport(a *app, args []string) error {
+	const usage = "import [-action create|upsert|update|emplace] <collection> [file.jsonl]"
+
+	fs := flags(usage)
+	action := fs.String("action", "", "import action (default create)")
+	batch := fs.Int("batch", 1000, "number of documents per import request")
+	workers := fs.Int("workers", 2, "number of concurrent import requests")
+	_ = fs.Parse(args)
+	args = fs.Args()
+
+	err := nargs(args, 1, 2, usage)
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(args) == 2 && args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	bi := a.c.NewBulkIndexer(tsclient.BulkIndexerConfig{
+		Workers:        *workers,
+		FlushDocuments: *batch,
+		FlushInterval:  -1,
+		OnFailure: func(item tsclient.BulkIndexerItem, err error) {
+			fmt.Fprintf(os.Stderr, "%v: %s\n", err, item.Document)
+		},
+	})
+
+	s := bufio.NewScanner(in)
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		b := bytes.TrimSpace(s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if !json.Valid(b) {
+			bi.Close()
+			return fmt.Errorf("line %v is not a valid JSON document", line)
+		}
+
+		err = bi.Add(args[0], *action, json.RawMessage(b))
+		if err != nil {
+			bi.Close()
+			return err
+		}
+	}
+	bi.Close()
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	st := bi.Stats()
+	err = a.print(result{
+		value:   map[string]uint64{"num_imported": st.Indexed, "num_failed": st.Failed},
+		columns: []string{"IMPORTED", "FAILED"},
+		rows:    [][]string{{strconv.FormatUint(st.Indexed, 10), strconv.FormatUint(st.Failed, 10)}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if st.Failed > 0 {
+		return fmt.Errorf("%v documents failed to import", st.Failed)
+	}
+	return nil
+}
+
+func runExport(a *app, args []string) (err error) {
+	const usage = "export [-filter_by filter] [-include_fields a,b] [-exclude_fields a,b] <collection> [file.jsonl]"
+
+	var data tsclient.ExportData
+	fs := flags(usage)
+	fs.StringVar(&data.FilterBy, "filter_by", "", "only export documents matching this filter")
+	fs.Func("include_fields", "comma-separated list of fields to export", func(s string) error {
+		data.IncludeFields = splitList(s)
+		return nil
+	})
+	fs.Func("exclude_fields", "comma-separated list of fields to leave out", func(s string) error {
+		data.ExcludeFields = splitList(s)
+		return nil
+	})
+	_ = fs.Parse(args)
+	args = fs.Args()
+
+	err = nargs(args, 1, 2, usage)
+	if err != nil {
+		return err
+	}
+
+	out := a.out
+	if len(args) == 2 && args[1] != "-" {
+		f, ferr := os.Create(args[1])
+		if ferr != nil {
+			return ferr
+		}
+		// the export isn't complete until the file is flushed to disk
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		out = f
+	}
+
+	body, err := a.c.ExportReader(args[0], data)
+	if err = a.check(err); err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(out, body)
+	return err
+}
@@ -0,0 +1,247 @@
+// Command tsctl manages a Typesense server from the command line.
+//
+// Usage:
+//
+//	tsctl [flags] <command> [arguments]
+//
+// The server URL and API key are read from the -url and -key flags,
+// the TYPESENSE_* environment variables, or a profile in the config file, in that order.
+// See tsclient.ConfigFromEnv for the environment variables and tsclient.LoadConfig for the config file format.
+//
+// Run tsctl without arguments for a list of commands.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+// command is a tsctl command or subcommand.
+type command struct {
+	usage string
+	help  string
+	run   func(a *app, args []string) error
+	// subcommands, if the command has no run function of its own
+	sub map[string]*command
+}
+
+var commands = map[string]*command{
+	"health":      {usage: "health", help: "check the server's health", run: runHealth},
+	"collections": collectionCommands,
+	"documents":   documentCommands,
+	"import":      {usage: "import [-action create|upsert|update|emplace] <collection> [file.jsonl]", help: "import JSONL documents from a file or stdin", run: runImport},
+	"export":      {usage: "export [-filter_by filter] [-include_fields a,b] [-exclude_fields a,b] <collection> [file.jsonl]", help: "export documents as JSONL to a file or stdout", run: runExport},
+	"search":      {usage: "search [search flags] <collection>", help: "search a collection", run: runSearch},
+	"shell":       {usage: "shell <collection>", help: "search a collection interactively, tuning search parameters between queries", run: runShell},
+	"keys":        keyCommands,
+	"synonyms":    synonymCommands,
+	"overrides":   overrideCommands,
+	"aliases":     aliasCommands,
+}
+
+// app is the state shared by all commands.
+type app struct {
+	c      *tsclient.Client
+	format string
+	out    io.Writer
+
+	mu   sync.Mutex
+	last *tsclient.RequestInfo
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("tsctl: ")
+
+	var cfg config
+	flag.StringVar(&cfg.URL, "url", "", "Typesense URL, or a comma-separated list of node URLs")
+	flag.StringVar(&cfg.APIKey, "key", "", "Typesense API key")
+	flag.StringVar(&cfg.Profile, "profile", "", "profile to use from the config file")
+	flag.StringVar(&cfg.File, "config", "", "path to a JSON config file")
+	flag.DurationVar(&cfg.Timeout, "timeout", 0, "timeout for the whole command (0 for none); use TYPESENSE_TIMEOUT for a per-request timeout")
+	format := flag.String("o", "table", "output format: table, json or jsonl")
+	verbose := flag.Bool("v", false, "log requests to stderr")
+	flag.Usage = usage
+	flag.Parse()
+
+	switch *format {
+	case "table", "json", "jsonl":
+	default:
+		log.Fatalf("unknown output format %q", *format)
+	}
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args, err := lookup(flag.Args())
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+
+	clientConfig, err := cfg.client()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the timeout covers connecting, too
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	// connect with the context, instead of letting NewFromConfig ping the server without it
+	lazy := clientConfig.Lazy
+	clientConfig.Lazy = true
+	c, err := tsclient.NewFromConfig(clientConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *verbose {
+		c.Logger = stderrLogger{}
+	}
+	c = c.WithContext(ctx)
+
+	if !lazy {
+		_, err = c.Health()
+		if err != nil {
+			log.Fatalf("connecting to %v: %v", strings.Join(clientConfig.Nodes, ","), err)
+		}
+	}
+
+	a := &app{
+		c:      c,
+		format: *format,
+		out:    os.Stdout,
+	}
+	a.c.Use(tsclient.Hooks(nil, a.record))
+
+	err = cmd.run(a, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// lookup finds the command named by args, returning it and its remaining arguments.
+func lookup(args []string) (*command, []string, error) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command %q, run tsctl -h for a list of commands", args[0])
+	}
+	args = args[1:]
+
+	for cmd.sub != nil {
+		if len(args) == 0 {
+			return nil, nil, fmt.Errorf("usage:\n%v", subUsage(cmd))
+		}
+
+		sub, ok := cmd.sub[args[0]]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown subcommand %q, usage:\n%v", args[0], subUsage(cmd))
+		}
+		cmd, args = sub, args[1:]
+	}
+	return cmd, args, nil
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: tsctl [flags] <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		if cmd.sub != nil {
+			fmt.Fprint(w, subUsage(cmd))
+			continue
+		}
+		fmt.Fprintf(w, "  %v\n    \t%v\n", cmd.usage, cmd.help)
+	}
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func subUsage(cmd *command) string {
+	names := make([]string, 0, len(cmd.sub))
+	for name := range cmd.sub {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %v\n    \t%v\n", cmd.sub[name].usage, cmd.sub[name].help)
+	}
+	return b.String()
+}
+
+// flags returns a FlagSet for a command's own flags.
+func flags(usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(strings.Fields(usage)[0], flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tsctl %v\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// nargs returns an error if the number of arguments isn't between lo and hi.
+func nargs(args []string, lo, hi int, usage string) error {
+	if len(args) < lo || len(args) > hi {
+		return fmt.Errorf("usage: tsctl %v", usage)
+	}
+	return nil
+}
+
+func runHealth(a *app, args []string) error {
+	ok, err := a.c.Health()
+	if err = a.check(err); err != nil {
+		return err
+	}
+
+	return a.print(result{
+		value:   map[string]bool{"ok": ok},
+		columns: []string{"OK"},
+		rows:    [][]string{{fmt.Sprint(ok)}},
+	})
+}
+
+// stderrLogger logs requests to stderr.
+type stderrLogger struct{}
+
+func (stderrLogger) Debug(msg string, args ...interface{}) { logArgs("DEBUG", msg, args) }
+func (stderrLogger) Info(msg string, args ...interface{})  { logArgs("INFO", msg, args) }
+func (stderrLogger) Warn(msg string, args ...interface{})  { logArgs("WARN", msg, args) }
+func (stderrLogger) Error(msg string, args ...interface{}) { logArgs("ERROR", msg, args) }
+
+func logArgs(level, msg string, args []interface{}) {
+	var b strings.Builder
+	b.WriteString(time.Now().Format("15:04:05.000") + " " + level + " " + msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	fmt.Fprintln(os.Stderr, b.String())
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/termora/tsclient"
+)
+
+// result is the output of a command.
+type result struct {
+	// Printed as indented JSON, or as compact JSON for jsonl output.
+	// Slices are printed one element per line for jsonl output.
+	value interface{}
+
+	// Columns and rows for table output. If columns is empty, value is printed as indented JSON instead.
+	columns []string
+	rows    [][]string
+}
+
+func (a *app) print(res result) error {
+	switch {
+	case a.format == "jsonl":
+		enc := json.NewEncoder(a.out)
+
+		v := reflect.ValueOf(res.value)
+		if v.Kind() != reflect.Slice {
+			return enc.Encode(res.value)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			err := enc.Encode(v.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case a.format == "json" || len(res.columns) == 0:
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res.value)
+
+	default:
+		tw := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(res.columns, "\t"))
+		for _, row := range res.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// record is called after every request, to keep the response for error messages.
+func (a *app) record(info *tsclient.RequestInfo) {
+	a.mu.Lock()
+	a.last = info
+	a.mu.Unlock()
+}
+
+// reset forgets the last request, so that a command failing before it makes a request
+// isn't reported with the response to an earlier one.
+func (a *app) reset() {
+	a.mu.Lock()
+	a.last = nil
+	a.mu.Unlock()
+}
+
+// check adds the error message returned by Typesense to err.
+// It also returns an error for 400 responses, which the client doesn't return an error for.
+// Each request's response is only checked once.
+func (a *app) check(err error) error {
+	a.mu.Lock()
+	last := a.last
+	a.last = nil
+	a.mu.Unlock()
+
+	if last == nil || last.StatusCode < http.StatusBadRequest {
+		return err
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(last.Body, &body)
+
+	msg := fmt.Sprintf("%v %v", last.StatusCode, http.StatusText(last.StatusCode))
+	if body.Message != "" {
+		msg += ": " + body.Message
+	}
+	return fmt.Errorf("%v %v: %v", last.Method, last.Endpoint, msg)
+}
+
+// request makes a raw request, for endpoints the client doesn't have methods for.
+func (a *app) request(method, endpoint string, body interface{}) (json.RawMessage, error) {
+	var opts []tsclient.RequestOption
+	if body != nil {
+		opts = append(opts, tsclient.WithJSONBody(body))
+	}
+
+	resp, err := a.c.Request(method, endpoint, opts...)
+	return resp, a.check(err)
+}
+
+// objects decodes a list of JSON objects, optionally wrapped in an object under key.
+func objects(resp []byte, key string) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	dec.UseNumber()
+
+	if key == "" {
+		var objs []map[string]interface{}
+		return objs, dec.Decode(&objs)
+	}
+
+	var wrapped map[string][]map[string]interface{}
+	err := dec.Decode(&wrapped)
+	return wrapped[key], err
+}
+
+// table returns a result printing objs as a table with the given fields as columns.
+// If no fields are given, all fields of the objects are used.
+func table(objs []map[string]interface{}, fields ...string) result {
+	if len(fields) == 0 {
+		seen := map[string]bool{}
+		for _, obj := range objs {
+			for k := range obj {
+				if !seen[k] {
+					seen[k] = true
+					fields = append(fields, k)
+				}
+			}
+		}
+		sort.Strings(fields)
+	}
+
+	res := result{value: objs}
+	for _, f := range fields {
+		res.columns = append(res.columns, strings.ToUpper(f))
+	}
+
+	for _, obj := range objs {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = cell(obj[f])
+		}
+		res.rows = append(res.rows, row)
+	}
+	return res
+}
+
+// cell formats a JSON value for a table cell.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		s := make([]string, len(v))
+		for i := range v {
+			s[i] = cell(v[i])
+		}
+		return strings.Join(s, ",")
+	case map[string]interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/termora/tsclient"
+)
+
+// searchParam is a search parameter, named as in the Typesense API.
+type searchParam struct {
+	name  string
+	usage string
+	set   func(data *tsclient.SearchData, s string) error
+}
+
+// searchParams are the search parameters that can be set from the command line.
+var searchParams = []searchParam{
+	{"q", "the query text", func(d *tsclient.SearchData, s string) error { d.Query = s; return nil }},
+	{"query_by", "comma-separated fields to search in", func(d *tsclient.SearchData, s string) error { d.QueryBy = splitList(s); return nil }},
+	{"query_by_weights", "comma-separated weights for the query_by fields", func(d *tsclient.SearchData, s string) (err error) {
+		d.QueryByWeights, err = intList(s)
+		return err
+	}},
+	{"infix", "comma-separated infix modes for the query_by fields (off, always, fallback)", func(d *tsclient.SearchData, s string) error { d.Infix = splitList(s); return nil }},
+	{"prefix", "comma-separated booleans enabling prefix search for the query_by fields", func(d *tsclient.SearchData, s string) (err error) {
+		d.Prefix, err = boolList(s)
+		return err
+	}},
+	{"filter_by", "filter conditions", func(d *tsclient.SearchData, s string) error { d.FilterBy = s; return nil }},
+	{"sort_by", "comma-separated sort fields, such as rank:desc", func(d *tsclient.SearchData, s string) error { d.SortBy = splitList(s); return nil }},
+	{"facet_by", "comma-separated fields to facet on", func(d *tsclient.SearchData, s string) error { d.FacetBy = splitList(s); return nil }},
+	{"max_facet_values", "maximum number of facet values returned", intParam(func(d *tsclient.SearchData, i int) { d.MaxFacetValues = i })},
+	{"facet_query", "filter facet values, such as tags:foo", func(d *tsclient.SearchData, s string) error { d.FacetQuery = s; return nil }},
+	{"prioritize_exact_match", "rank exact matches first", boolParam(func(d *tsclient.SearchData, b bool) { d.NoPrioritizeExactMatch = !b })},
+	{"page", "page number", intParam(func(d *tsclient.SearchData, i int) { d.Page = i })},
+	{"per_page", "number of hits per page", intParam(func(d *tsclient.SearchData, i int) { d.PerPage = i })},
+	{"group_by", "comma-separated fields to group hits by", func(d *tsclient.SearchData, s string) error { d.GroupBy = splitList(s); return nil }},
+	{"group_limit", "maximum number of hits per group", intParam(func(d *tsclient.SearchData, i int) { d.GroupLimit = i })},
+	{"include_fields", "comma-separated fields to return", func(d *tsclient.SearchData, s string) error { d.IncludeFields = splitList(s); return nil }},
+	{"exclude_fields", "comma-separated fields to leave out", func(d *tsclient.SearchData, s string) error { d.ExcludeFields = splitList(s); return nil }},
+	{"include_embeddings", "return generated embedding fields, which are left out by default", boolParam(func(d *tsclient.SearchData, b bool) { d.IncludeEmbeddings = b })},
+	{"highlight_fields", "comma-separated fields to highlight", func(d *tsclient.SearchData, s string) error { d.HighlightFields = splitList(s); return nil }},
+	{"highlight_full_fields", "comma-separated fields to highlight without snippeting", func(d *tsclient.SearchData, s string) error {
+		d.HighlightFullFields = splitList(s)
+		return nil
+	}},
+	{"highlight_affix_num_tokens", "number of tokens around highlighted text", intParam(func(d *tsclient.SearchData, i int) { d.HighlightAffixNumTokens = i })},
+	{"highlight_start_tag", "tag inserted before highlighted text", func(d *tsclient.SearchData, s string) error { d.HighlightStartTag = &s; return nil }},
+	{"highlight_end_tag", "tag inserted after highlighted text", func(d *tsclient.SearchData, s string) error { d.HighlightEndTag = &s; return nil }},
+	{"snippet_threshold", "field length under which fields are fully highlighted", intParam(func(d *tsclient.SearchData, i int) { d.SnippetThreshold = i })},
+	{"num_typos", "maximum number of typos (0, 1 or 2)", intParam(func(d *tsclient.SearchData, i int) { d.NumTypos = &i })},
+	{"typo_tokens_threshold", "number of results below which more typos are tried", intParam(func(d *tsclient.SearchData, i int) { d.TypoTokensThreshold = &i })},
+	{"drop_tokens_threshold", "number of results below which query tokens are dropped", intParam(func(d *tsclient.SearchData, i int) { d.DropTokensThreshold = &i })},
+	{"pinned_hits", "comma-separated id:position pairs to pin", func(d *tsclient.SearchData, s string) error { d.PinnedHits = splitList(s); return nil }},
+	{"enable_overrides", "apply overrides", boolParam(func(d *tsclient.SearchData, b bool) { d.DisableOverrides = !b })},
+	{"limit_hits", "maximum number of hits that can be fetched", intParam(func(d *tsclient.SearchData, i int) { d.LimitHits = i })},
+	{"vector_query", "nearest neighbor query: field:[v1,v2,...] or field:id=ID", func(d *tsclient.SearchData, s string) (err error) {
+		d.VectorQuery, err = parseVectorQuery(s)
+		return err
+	}},
+	{"use_cache", "use the server-side search cache", boolParam(func(d *tsclient.SearchData, b bool) { d.UseCache = b })},
+	{"cache_ttl", "seconds to keep results in the server-side cache", intParam(func(d *tsclient.SearchData, i int) { d.CacheTTL = i })},
+}
+
+// lookupParam returns the search parameter with the given name.
+func lookupParam(name string) (searchParam, bool) {
+	for _, p := range searchParams {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return searchParam{}, false
+}
+
+func intParam(set func(d *tsclient.SearchData, i int)) func(d *tsclient.SearchData, s string) error {
+	return func(d *tsclient.SearchData, s string) error {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		set(d, i)
+		return nil
+	}
+}
+
+func boolParam(set func(d *tsclient.SearchData, b bool)) func(d *tsclient.SearchData, s string) error {
+	return func(d *tsclient.SearchData, s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		set(d, b)
+		return nil
+	}
+}
+
+// splitList splits a comma-separated list, trimming spaces.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func intList(s string) ([]int, error) {
+	var out []int
+	for _, p := range splitList(s) {
+		i, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", p)
+		}
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+func boolList(s string) ([]bool, error) {
+	var out []bool
+	for _, p := range splitList(s) {
+		b, err := strconv.ParseBool(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", p)
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// parseVectorQuery parses a vector query in the format field:[v1,v2,...] or field:id=ID.
+func parseVectorQuery(s string) (*tsclient.VectorQuery, error) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid vector query %q, expected field:[v1,v2,...] or field:id=ID", s)
+	}
+
+	q := &tsclient.VectorQuery{Field: s[:i]}
+	v := strings.TrimSpace(s[i+1:])
+
+	if strings.HasPrefix(v, "id=") {
+		q.ID = strings.TrimPrefix(v, "id=")
+		return q, nil
+	}
+
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("invalid vector query %q, expected field:[v1,v2,...] or field:id=ID", s)
+	}
+
+	for _, p := range splitList(v[1 : len(v)-1]) {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", p)
+		}
+		q.Vector = append(q.Vector, f)
+	}
+	return q, nil
+}
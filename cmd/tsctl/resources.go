@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/termora/tsclient"
+)
+
+// Keys, synonyms, overrides and aliases don't have client methods, so they're managed with raw requests.
+
+var keyCommands = &command{sub: map[string]*command{
+	"list": {usage: "keys list", help: "list API keys", run: runKeysList},
+	"get":  {usage: "keys get <id>", help: "show an API key", run: runKeysGet},
+	"create": {
+		usage: "keys create [-description text] [-collections a,b] [-expires_at unix] <action,...>",
+		help:  "create an API key allowed to perform the given actions, such as documents:search",
+		run:   runKeysCreate,
+	},
+	"delete": {usage: "keys delete <id>", help: "delete an API key", run: runKeysDelete},
+}}
+
+var keyFields = []string{"id", "description", "actions", "collections", "value_prefix", "expires_at"}
+
+func runKeysList(a *app, args []string) error {
+	err := nargs(args, 0, 0, "keys list")
+	if err != nil {
+		return err
+	}
+	return a.list("/keys", "keys", keyFields...)
+}
+
+func runKeysGet(a *app, args []string) error {
+	err := nargs(args, 1, 1, "keys get <id>")
+	if err != nil {
+		return err
+	}
+	return a.object("GET", tsclient.Path("keys", args[0]), nil)
+}
+
+func runKeysCreate(a *app, args []string) error {
+	const usage = "keys create [-description text] [-collections a,b] [-expires_at unix] <action,...>"
+
+	fs := flags(usage)
+	description := fs.String("description", "", "description of the key")
+	collections := fs.String("collections", "*", "comma-separated collections the key can access")
+	expiresAt := fs.Int64("expires_at", 0, "Unix timestamp at which the key expires")
+	_ = fs.Parse(args)
+
+	err := nargs(fs.Args(), 1, 1, usage)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"description": *description,
+		"actions":     splitList(fs.Arg(0)),
+		"collections": splitList(*collections),
+	}
+	if *expiresAt != 0 {
+		body["expires_at"] = *expiresAt
+	}
+
+	// the full key is only returned when it is created, so always show it
+	return a.object("POST", "/keys", body)
+}
+
+func runKeysDelete(a *app, args []string) error {
+	err := nargs(args, 1, 1, "keys delete <id>")
+	if err != nil {
+		return err
+	}
+	return a.object("DELETE", tsclient.Path("keys", args[0]), nil)
+}
+
+var synonymCommands = &command{sub: map[string]*command{
+	"list": {usage: "synonyms list <collection>", help: "list a collection's synonyms", run: runSynonymsList},
+	"get":  {usage: "synonyms get <collection> <id>", help: "show a synonym", run: collectionResource("synonyms", "get")},
+	"put": {
+		usage: "synonyms put [-root word] <collection> <id> <synonym,...>",
+		help:  "create or replace a synonym; with -root, the synonyms are one-way",
+		run:   runSynonymsPut,
+	},
+	"delete": {usage: "synonyms delete <collection> <id>", help: "delete a synonym", run: collectionResource("synonyms", "delete")},
+}}
+
+func runSynonymsList(a *app, args []string) error {
+	err := nargs(args, 1, 1, "synonyms list <collection>")
+	if err != nil {
+		return err
+	}
+	return a.list(tsclient.Path("collections", args[0], "synonyms"), "synonyms", "id", "root", "synonyms")
+}
+
+func runSynonymsPut(a *app, args []string) error {
+	const usage = "synonyms put [-root word] <collection> <id> <synonym,...>"
+
+	fs := flags(usage)
+	root := fs.String("root", "", "the word the synonyms map to, for one-way synonyms")
+	_ = fs.Parse(args)
+
+	err := nargs(fs.Args(), 3, 3, usage)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{"synonyms": splitList(fs.Arg(2))}
+	if *root != "" {
+		body["root"] = *root
+	}
+	return a.object("PUT", tsclient.Path("collections", fs.Arg(0), "synonyms", fs.Arg(1)), body)
+}
+
+var overrideCommands = &command{sub: map[string]*command{
+	"list": {usage: "overrides list <collection>", help: "list a collection's overrides", run: runOverridesList},
+	"get":  {usage: "overrides get <collection> <id>", help: "show an override", run: collectionResource("overrides", "get")},
+	"put": {
+		usage: "overrides put <collection> <id> [file.json]",
+		help:  "create or replace an override from a JSON file or stdin",
+		run:   runOverridesPut,
+	},
+	"delete": {usage: "overrides delete <collection> <id>", help: "delete an override", run: collectionResource("overrides", "delete")},
+}}
+
+func runOverridesList(a *app, args []string) error {
+	err := nargs(args, 1, 1, "overrides list <collection>")
+	if err != nil {
+		return err
+	}
+	return a.list(tsclient.Path("collections", args[0], "overrides"), "overrides", "id", "rule", "includes", "excludes", "filter_by")
+}
+
+func runOverridesPut(a *app, args []string) error {
+	err := nargs(args, 2, 3, "overrides put <collection> <id> [file.json]")
+	if err != nil {
+		return err
+	}
+
+	b, err := readInput(args[2:])
+	if err != nil {
+		return err
+	}
+	if !json.Valid(b) {
+		return fmt.Errorf("input is not a valid JSON override")
+	}
+
+	return a.object("PUT", tsclient.Path("collections", args[0], "overrides", args[1]), json.RawMessage(b))
+}
+
+// collectionResource returns a command getting or deleting a synonym or override by ID.
+func collectionResource(kind, action string) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		err := nargs(args, 2, 2, kind+" "+action+" <collection> <id>")
+		if err != nil {
+			return err
+		}
+
+		method := "GET"
+		if action == "delete" {
+			method = "DELETE"
+		}
+		return a.object(method, tsclient.Path("collections", args[0], kind, args[1]), nil)
+	}
+}
+
+var aliasCommands = &command{sub: map[string]*command{
+	"list":   {usage: "aliases list", help: "list collection aliases", run: runAliasesList},
+	"get":    {usage: "aliases get <alias>", help: "show the collection an alias points to", run: runAliasesGet},
+	"put":    {usage: "aliases put <alias> <collection>", help: "create or update an alias", run: runAliasesPut},
+	"delete": {usage: "aliases delete <alias>", help: "delete an alias", run: runAliasesDelete},
+}}
+
+func runAliasesList(a *app, args []string) error {
+	err := nargs(args, 0, 0, "aliases list")
+	if err != nil {
+		return err
+	}
+	return a.list("/aliases", "aliases", "name", "collection_name")
+}
+
+func runAliasesGet(a *app, args []string) error {
+	err := nargs(args, 1, 1, "aliases get <alias>")
+	if err != nil {
+		return err
+	}
+	return a.object("GET", tsclient.Path("aliases", args[0]), nil)
+}
+
+func runAliasesPut(a *app, args []string) error {
+	err := nargs(args, 2, 2, "aliases put <alias> <collection>")
+	if err != nil {
+		return err
+	}
+	return a.object("PUT", tsclient.Path("aliases", args[0]), map[string]string{"collection_name": args[1]})
+}
+
+func runAliasesDelete(a *app, args []string) error {
+	err := nargs(args, 1, 1, "aliases delete <alias>")
+	if err != nil {
+		return err
+	}
+	return a.object("DELETE", tsclient.Path("aliases", args[0]), nil)
+}
+
+// list requests a list of objects wrapped under key, and prints them as a table with the given columns.
+func (a *app) list(endpoint, key string, fields ...string) error {
+	resp, err := a.request("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	objs, err := objects(resp, key)
+	if err != nil {
+		return err
+	}
+	return a.print(table(objs, fields...))
+}
+
+// object makes a request returning a single object, and prints it as a table of fields and values.
+func (a *app) object(method, endpoint string, body interface{}) error {
+	resp, err := a.request(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	return a.printDocument(resp)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/termora/tsclient"
+)
+
+func runSearch(a *app, args []string) error {
+	const usage = "search [search flags] <collection>"
+
+	data := tsclient.SearchData{Query: "*"}
+
+	fs := flags(usage)
+	for _, p := range searchParams {
+		p := p
+		fs.Func(p.name, p.usage, func(s string) error { return p.set(&data, s) })
+	}
+	fields := fs.String("fields", "", "comma-separated document fields shown in table output (default: all)")
+	_ = fs.Parse(args)
+
+	err := nargs(fs.Args(), 1, 1, usage)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.c.Search(fs.Arg(0), data)
+	if err = a.check(err); err != nil {
+		return err
+	}
+
+	if a.format != "table" {
+		if a.format == "jsonl" {
+			docs := make([]json.RawMessage, len(res.Hits))
+			for i, hit := range res.Hits {
+				docs[i] = json.RawMessage(hit.Document)
+			}
+			return a.print(result{value: docs})
+		}
+		return a.print(result{value: res})
+	}
+
+	var docs []map[string]interface{}
+	for _, hit := range res.Hits {
+		var m map[string]interface{}
+		err = hit.Document.UnmarshalTo(&m)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, m)
+	}
+
+	t := table(docs, splitList(*fields)...)
+	t.columns = append([]string{"TEXT_MATCH"}, t.columns...)
+	for i := range t.rows {
+		t.rows[i] = append([]string{strconv.Itoa(res.Hits[i].TextMatch)}, t.rows[i]...)
+	}
+
+	err = a.print(t)
+	if err != nil {
+		return err
+	}
+
+	for _, fc := range res.FacetCounts {
+		ft := result{columns: []string{strings.ToUpper(fc.FieldName), "COUNT"}}
+		for _, v := range fc.Counts {
+			ft.rows = append(ft.rows, []string{v.Value, strconv.Itoa(v.Count)})
+		}
+
+		fmt.Fprintln(a.out)
+		err = a.print(ft)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(a.out, "\n%v of %v documents found in %vms\n", res.Found, res.OutOf, res.SearchTime)
+	return err
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+const shellHelp = `Type a query to search, or a command:
+  :set <param> <value>  set a search parameter, such as :set query_by name,aliases or :set num_typos 1
+  :unset <param>        unset a search parameter
+  :facet <field,...>    facet on fields (shortcut for :set facet_by); :facet with no fields turns faceting off
+  :fields <field,...>   document fields shown for hits without highlights (default: query_by fields)
+  :use <collection>     switch collections
+  :show                 show the current parameters
+  :params               list all search parameters
+  :help                 show this help
+  :quit                 exit the shell
+An empty line repeats the last query.
+`
+
+// shell is an interactive search session.
+type shell struct {
+	a *app
+	w io.Writer
+
+	collection string
+	// search parameters set with :set, by name
+	params map[string]string
+	fields []string
+	query  string
+
+	// whether to style output with ANSI escape codes
+	color bool
+}
+
+func runShell(a *app, args []string) error {
+	err := nargs(args, 1, 1, "shell <collection>")
+	if err != nil {
+		return err
+	}
+
+	sh := &shell{
+		a:          a,
+		w:          a.out,
+		collection: args[0],
+		params:     map[string]string{},
+		color:      isTerminal(os.Stdout),
+	}
+
+	// the collection's indexed string fields are queried by default
+	col, err := a.c.Collection(sh.collection)
+	if err = a.check(err); err != nil {
+		return err
+	}
+	sh.defaultQueryBy(col)
+
+	fmt.Fprintf(sh.w, "Searching %v (%v documents). Type :help for help.\n", col.Name, col.NumDocuments)
+	sh.show()
+
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stderr, "%v> ", sh.collection)
+		if !in.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return in.Err()
+		}
+
+		sh.a.reset()
+		line := strings.TrimSpace(in.Text())
+		if strings.HasPrefix(line, ":") {
+			if sh.command(line) {
+				return nil
+			}
+			continue
+		}
+
+		if line != "" {
+			sh.query = line
+		}
+		sh.search()
+	}
+}
+
+func (sh *shell) defaultQueryBy(col tsclient.Collection) {
+	var fields []string
+	for _, f := range col.Fields {
+		if (f.Type == "string" || f.Type == "string[]") && f.Index {
+			fields = append(fields, f.Name)
+		}
+	}
+
+	if len(fields) > 0 {
+		sh.params["query_by"] = strings.Join(fields, ",")
+	}
+}
+
+// command runs a shell command, returning true if the shell should exit.
+func (sh *shell) command(line string) (quit bool) {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i != -1 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case ":q", ":quit", ":exit":
+		return true
+	case ":h", ":help":
+		fmt.Fprint(sh.w, shellHelp)
+	case ":set":
+		param, value := arg, ""
+		if i := strings.IndexAny(arg, " \t"); i != -1 {
+			param, value = arg[:i], strings.TrimSpace(arg[i+1:])
+		}
+		sh.set(param, value)
+	case ":unset":
+		delete(sh.params, arg)
+	case ":facet":
+		if arg == "" {
+			delete(sh.params, "facet_by")
+			break
+		}
+		sh.set("facet_by", arg)
+	case ":fields":
+		sh.fields = splitList(arg)
+	case ":use":
+		col, err := sh.a.c.Collection(arg)
+		if err = sh.a.check(err); err != nil {
+			sh.error(err)
+			break
+		}
+		sh.collection = col.Name
+		delete(sh.params, "query_by")
+		sh.defaultQueryBy(col)
+		sh.show()
+	case ":show":
+		sh.show()
+	case ":params":
+		for _, p := range searchParams {
+			fmt.Fprintf(sh.w, "  %-28v %v\n", p.name, p.usage)
+		}
+	default:
+		sh.error(fmt.Errorf("unknown command %v, type :help for help", name))
+	}
+	return false
+}
+
+// set sets a search parameter, checking that its value is valid.
+func (sh *shell) set(name, value string) {
+	p, ok := lookupParam(name)
+	if !ok {
+		sh.error(fmt.Errorf("unknown parameter %q, type :params for a list", name))
+		return
+	}
+
+	err := p.set(&tsclient.SearchData{}, value)
+	if err != nil {
+		sh.error(fmt.Errorf("%v: %w", name, err))
+		return
+	}
+	sh.params[name] = value
+}
+
+func (sh *shell) show() {
+	names := make([]string, 0, len(sh.params))
+	for name := range sh.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(sh.w, "  %v = %v\n", name, sh.params[name])
+	}
+}
+
+// data returns the SearchData for the current query and parameters.
+func (sh *shell) data() tsclient.SearchData {
+	data := tsclient.SearchData{Query: sh.query}
+	if data.Query == "" {
+		data.Query = "*"
+	}
+
+	for name, value := range sh.params {
+		p, _ := lookupParam(name)
+		// values are checked when they're set
+		_ = p.set(&data, value)
+	}
+	return data
+}
+
+func (sh *shell) search() {
+	data := sh.data()
+
+	start := time.Now()
+	res, err := sh.a.c.Search(sh.collection, data)
+	elapsed := time.Since(start)
+	if err = sh.a.check(err); err != nil {
+		sh.error(err)
+		return
+	}
+
+	fields := sh.fields
+	if len(fields) == 0 {
+		fields = data.QueryBy
+	}
+
+	perPage, page := data.PerPage, res.Page
+	if perPage == 0 {
+		perPage = 10
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	for i, hit := range res.Hits {
+		sh.hit((page-1)*perPage+i+1, hit, fields)
+	}
+
+	for _, fc := range res.FacetCounts {
+		values := make([]string, len(fc.Counts))
+		for i, v := range fc.Counts {
+			values[i] = fmt.Sprintf("%v (%v)", v.Value, v.Count)
+		}
+		fmt.Fprintf(sh.w, "%v: %v\n", sh.style(fc.FieldName, ansiBold), strings.Join(values, ", "))
+	}
+
+	fmt.Fprintln(sh.w, sh.style(fmt.Sprintf("%v of %v documents found, page %v, search took %vms (%v round trip)",
+		res.Found, res.OutOf, res.Page, res.SearchTime, elapsed.Round(time.Millisecond)), ansiDim))
+}
+
+// hit prints a single hit, with its highlighted snippets if it has any.
+func (sh *shell) hit(rank int, hit tsclient.SearchHit, fields []string) {
+	var doc map[string]interface{}
+	_ = hit.Document.UnmarshalTo(&doc)
+
+	header := fmt.Sprintf("%3v. %v", rank, cell(doc["id"]))
+	score := fmt.Sprintf("text_match=%v", hit.TextMatch)
+	if hit.VectorDistance != nil {
+		score += fmt.Sprintf(" vector_distance=%.4f", *hit.VectorDistance)
+	}
+	fmt.Fprintf(sh.w, "%v  %v\n", sh.style(header, ansiBold), sh.style(score, ansiDim))
+
+	highlighted := map[string]bool{}
+	for _, hl := range hit.Highlights {
+		highlighted[hl.Field] = true
+
+		snippets := hl.Snippets
+		if hl.Snippet != "" {
+			snippets = []string{hl.Snippet}
+		}
+		fmt.Fprintf(sh.w, "     %v: %v\n", hl.Field, sh.highlight(strings.Join(snippets, " | ")))
+	}
+
+	for _, f := range fields {
+		if highlighted[f] || doc[f] == nil {
+			continue
+		}
+		fmt.Fprintf(sh.w, "     %v: %v\n", f, cell(doc[f]))
+	}
+}
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+func (sh *shell) style(s, code string) string {
+	if !sh.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// highlight renders Typesense's <mark> highlight tags as bold text, or removes them if color is disabled.
+func (sh *shell) highlight(s string) string {
+	start, end := "", ""
+	if sh.color {
+		start, end = ansiBold, ansiReset
+	}
+	return strings.NewReplacer("<mark>", start, "</mark>", end).Replace(s)
+}
+
+func (sh *shell) error(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+}
+
+// isTerminal returns true if f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
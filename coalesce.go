@@ -0,0 +1,91 @@
+package tsclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EnableSearchCoalescing makes identical concurrent searches share a single request.
+// Searches are identical if they are made to the same collection with the same encoded parameters.
+//
+// Every caller waits for the shared request using its own context (see WithContext).
+// The shared request is only canceled once every caller waiting for it has given up.
+//
+// EnableSearchCoalescing is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) EnableSearchCoalescing() {
+	c.coalescer = &coalescer{calls: map[string]*coalescedCall{}}
+}
+
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	body    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do calls fn once for all concurrent callers with the same key.
+// fn is called with a context that is only canceled once every caller's ctx is done.
+func (co *coalescer) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	co.mu.Lock()
+	call, ok := co.calls[key]
+	if !ok {
+		sharedCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &coalescedCall{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		co.calls[key] = call
+
+		go func() {
+			call.body, call.err = fn(sharedCtx)
+			cancel()
+
+			co.mu.Lock()
+			co.remove(key, call)
+			co.mu.Unlock()
+
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	co.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.body, call.err
+	case <-ctx.Done():
+		co.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			co.remove(key, call)
+		}
+		co.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// remove removes call from the map, if it hasn't already been replaced by a newer call. co.mu must be held.
+func (co *coalescer) remove(key string, call *coalescedCall) {
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+}
+
+// detachedContext keeps the values of its parent, but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
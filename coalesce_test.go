@@ -0,0 +1,185 @@
+package tsclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// waitForWaiters waits until n callers are waiting for the call with the given key.
+func waitForWaiters(t *testing.T, co *coalescer, key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		co.mu.Lock()
+		call, ok := co.calls[key]
+		waiters := 0
+		if ok {
+			waiters = call.waiters
+		}
+		co.mu.Unlock()
+
+		if waiters == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%v callers waiting, want %v", waiters, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescerShares(t *testing.T) {
+	co := &coalescer{calls: map[string]*coalescedCall{}}
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("result"), nil
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	results := make(chan result, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			body, err := co.do(context.Background(), "key", fn)
+			results <- result{body, err}
+		}()
+	}
+
+	waitForWaiters(t, co, "key", 3)
+	close(release)
+
+	for i := 0; i < 3; i++ {
+		res := <-results
+		if res.err != nil || string(res.body) != "result" {
+			t.Errorf("got %q, %v", res.body, res.err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("fn called %v times, want 1", n)
+	}
+
+	// finished calls are removed, so the next caller makes a new request
+	_, _ = co.do(context.Background(), "key", func(context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	})
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("fn called %v times after the first call finished, want 2", n)
+	}
+}
+
+func TestCoalescerCancel(t *testing.T) {
+	co := &coalescer{calls: map[string]*coalescedCall{}}
+
+	sharedCanceled := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		close(sharedCanceled)
+		return nil, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := co.do(ctx1, "key", fn)
+		errs <- err
+	}()
+	waitForWaiters(t, co, "key", 1)
+	go func() {
+		_, err := co.do(ctx2, "key", fn)
+		errs <- err
+	}()
+	waitForWaiters(t, co, "key", 2)
+
+	// the first caller giving up doesn't cancel the request the second is still waiting for
+	cancel1()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller: got %v, want context.Canceled", err)
+	}
+
+	select {
+	case <-sharedCanceled:
+		t.Fatal("shared request canceled while a caller was still waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+	waitForWaiters(t, co, "key", 1)
+
+	// the last caller giving up cancels it
+	cancel2()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("second caller: got %v, want context.Canceled", err)
+	}
+
+	select {
+	case <-sharedCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shared request not canceled after every caller gave up")
+	}
+
+	co.mu.Lock()
+	n := len(co.calls)
+	co.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%v calls left in the coalescer", n)
+	}
+}
+
+func TestSearchCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the schema lookup for embedding fields
+		if r.URL.Path == "/collections/terms" {
+			_, _ = w.Write([]byte(`{"name": "terms", "fields": [{"name": "name", "type": "string"}]}`))
+			return
+		}
+
+		atomic.AddInt32(&requests, 1)
+		<-release
+		_, _ = w.Write([]byte(`{"found": 1, "hits": [{"document": {"id": "1"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewLazy(srv.URL, "key")
+	c.EnableSearchCoalescing()
+
+	data := SearchData{Query: "plural", QueryBy: []string{"name"}}
+	key := "terms?" + data.values().Encode()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.Search("terms", data)
+			if err != nil || res.Found != 1 || len(res.Hits) != 1 {
+				t.Errorf("got %+v, %v", res, err)
+			}
+		}()
+	}
+
+	waitForWaiters(t, c.coalescer, key, 5)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server got %v requests, want 1", n)
+	}
+}
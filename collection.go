@@ -17,11 +17,59 @@ type Field struct {
 	Facet bool   `json:"facet"`
 	Index bool   `json:"index"`
 	Infix bool   `json:"infix"`
+
+	// Number of dimensions for float[] fields used in vector searches.
+	NumDim int `json:"num_dim,omitempty"`
+
+	// Configuration for automatically generating embeddings from other fields.
+	// Only valid for float[] fields.
+	Embed *FieldEmbed `json:"embed,omitempty"`
+}
+
+// FieldEmbed configures a field's embeddings to be generated by Typesense.
+type FieldEmbed struct {
+	// The fields the embedding is generated from.
+	From []string `json:"from"`
+
+	ModelConfig ModelConfig `json:"model_config"`
+}
+
+// ModelConfig is the embedding model used for a field.
+type ModelConfig struct {
+	// The name of the model, for example "ts/all-MiniLM-L12-v2" for a built-in model
+	// or "openai/text-embedding-ada-002" for a remote model.
+	ModelName string `json:"model_name"`
+
+	// API key for remote models.
+	APIKey string `json:"api_key,omitempty"`
+	// Base URL for remote models, to use an OpenAI-compatible API other than OpenAI's.
+	URL string `json:"url,omitempty"`
+
+	// Prefix added to the text before it is embedded during indexing.
+	IndexingPrefix string `json:"indexing_prefix,omitempty"`
+	// Prefix added to the query before it is embedded during searching.
+	QueryPrefix string `json:"query_prefix,omitempty"`
+}
+
+// EmbeddingFields returns the names of all fields with embeddings generated by Typesense.
+// Search excludes these fields from search results unless SearchData.IncludeEmbeddings is set.
+func (col Collection) EmbeddingFields() (fields []string) {
+	for _, f := range col.Fields {
+		if f.Embed != nil {
+			fields = append(fields, f.Name)
+		}
+	}
+	return fields
 }
 
 // Collection gets a collection by name.
 func (c *Client) Collection(name string) (col Collection, err error) {
-	resp, err := c.Request("GET", "/collections/"+name)
+	endpoint, err := collectionPath(name)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.Request("GET", endpoint)
 	if err != nil {
 		return
 	}
@@ -46,7 +94,14 @@ func (c *Client) Collections() (cols []Collection, err error) {
 // DeleteCollection permanently drops a collection. This action cannot be undone.
 // For large collections, this might have an impact on read latencies.
 func (c *Client) DeleteCollection(name string) (col Collection, err error) {
-	resp, err := c.Request("DELETE", "/collections/"+name)
+	endpoint, err := collectionPath(name)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.Request("DELETE", endpoint)
+	c.cache.invalidate(name)
+	c.embeddings.invalidate(name)
 	if err != nil {
 		return
 	}
@@ -64,18 +119,30 @@ type CreateFieldData struct {
 	// false = index the field, true = don't index the field
 	NoIndex bool
 	Infix   bool
+
+	// Number of dimensions, required for float[] fields used in vector searches.
+	// Not needed if Embed is set.
+	NumDim int
+	// Configuration for automatically generating embeddings from other fields.
+	Embed *FieldEmbed
 }
 
 // CreateCollection creates a collection. defaultSortingField is optional and may be left empty.
 func (c *Client) CreateCollection(name, defaultSortingField string, fields []CreateFieldData) (col Collection, err error) {
+	if name == "" {
+		return col, ErrEmptyCollection
+	}
+
 	fs := []Field{}
 	for _, f := range fields {
 		fs = append(fs, Field{
-			Name:  f.Name,
-			Type:  f.Type,
-			Facet: f.Facet,
-			Index: !f.NoIndex,
-			Infix: f.Infix,
+			Name:   f.Name,
+			Type:   f.Type,
+			Facet:  f.Facet,
+			Index:  !f.NoIndex,
+			Infix:  f.Infix,
+			NumDim: f.NumDim,
+			Embed:  f.Embed,
 		})
 	}
 
@@ -84,6 +151,7 @@ func (c *Client) CreateCollection(name, defaultSortingField string, fields []Cre
 		DefaultSortingField: defaultSortingField,
 		Fields:              fs,
 	}))
+	c.embeddings.invalidate(name)
 	if err != nil {
 		return
 	}
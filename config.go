@@ -0,0 +1,349 @@
+package tsclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// Config is the configuration for a client created with NewFromConfig.
+// Only Nodes is required.
+type Config struct {
+	// URLs of the Typesense nodes.
+	Nodes  []string
+	APIKey string
+
+	// Timeout for each request, including reading the response body.
+	// Default: no timeout
+	Timeout time.Duration
+
+	// See Client.MaxRetries.
+	// Default: 0
+	MaxRetries int
+	// See Client.RetryBackoff.
+	// Default: 100 milliseconds
+	RetryBackoff time.Duration
+
+	// Default: "go/tsclient " + VERSION
+	UserAgent string
+
+	TLS TLSConfig
+
+	// Don't ping the server when creating the client. Use (*Client).WaitReady to wait until it is up.
+	Lazy bool
+}
+
+// TLSConfig configures TLS connections to Typesense. All fields are optional.
+type TLSConfig struct {
+	// Path to a PEM file with CA certificates to trust, in addition to the system's.
+	CAFile string
+	// Paths to a PEM client certificate and key, for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// Skip verifying the server's certificate. Only use this for testing.
+	InsecureSkipVerify bool
+}
+
+// Configuration settings read from environment variables, in upper case and prefixed with TYPESENSE_.
+// Config files use the same names.
+var configKeys = []string{
+	"nodes", "api_key", "timeout", "max_retries", "retry_backoff", "user_agent",
+	"tls_ca_file", "tls_cert_file", "tls_key_file", "tls_insecure_skip_verify", "lazy",
+}
+
+// set sets a setting from its string value. Lists are comma-separated.
+func (cfg *Config) set(key, value string) (err error) {
+	switch key {
+	case "nodes", "url":
+		cfg.Nodes = nil
+		for _, u := range strings.Split(value, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				cfg.Nodes = append(cfg.Nodes, u)
+			}
+		}
+	case "api_key":
+		cfg.APIKey = value
+	case "timeout":
+		cfg.Timeout, err = time.ParseDuration(value)
+	case "max_retries":
+		cfg.MaxRetries, err = strconv.Atoi(value)
+	case "retry_backoff":
+		cfg.RetryBackoff, err = time.ParseDuration(value)
+	case "user_agent":
+		cfg.UserAgent = value
+	case "tls_ca_file":
+		cfg.TLS.CAFile = value
+	case "tls_cert_file":
+		cfg.TLS.CertFile = value
+	case "tls_key_file":
+		cfg.TLS.KeyFile = value
+	case "tls_insecure_skip_verify":
+		cfg.TLS.InsecureSkipVerify, err = strconv.ParseBool(value)
+	case "lazy":
+		cfg.Lazy, err = strconv.ParseBool(value)
+	default:
+		return errors.Errorf("unknown setting %q", key)
+	}
+
+	return errors.Wrapf(err, "invalid value for %v", key)
+}
+
+// ConfigFromEnv reads the configuration from TYPESENSE_* environment variables:
+//
+//	TYPESENSE_NODES                     comma-separated node URLs (TYPESENSE_URL is also accepted)
+//	TYPESENSE_API_KEY
+//	TYPESENSE_TIMEOUT                   a duration, such as 5s
+//	TYPESENSE_MAX_RETRIES
+//	TYPESENSE_RETRY_BACKOFF             a duration, such as 100ms
+//	TYPESENSE_USER_AGENT
+//	TYPESENSE_TLS_CA_FILE
+//	TYPESENSE_TLS_CERT_FILE
+//	TYPESENSE_TLS_KEY_FILE
+//	TYPESENSE_TLS_INSECURE_SKIP_VERIFY  true or false
+//	TYPESENSE_LAZY                      true to skip pinging the server when creating the client
+//
+// If TYPESENSE_CONFIG is set, the configuration is first loaded from that file with LoadConfig,
+// using the profile named by TYPESENSE_PROFILE. Environment variables override settings from the file.
+func ConfigFromEnv() (cfg Config, err error) {
+	if path := os.Getenv("TYPESENSE_CONFIG"); path != "" {
+		cfg, err = LoadConfig(path, os.Getenv("TYPESENSE_PROFILE"))
+		if err != nil {
+			return cfg, err
+		}
+	} else if profile := os.Getenv("TYPESENSE_PROFILE"); profile != "" {
+		return cfg, errors.Errorf("TYPESENSE_PROFILE is set to %q, but TYPESENSE_CONFIG is not set", profile)
+	}
+
+	err = cfg.SetFromEnv()
+	return cfg, err
+}
+
+// SetFromEnv overrides settings in cfg with the TYPESENSE_* environment variables that are set.
+// See ConfigFromEnv for the variables used. TYPESENSE_CONFIG and TYPESENSE_PROFILE are ignored.
+func (cfg *Config) SetFromEnv() error {
+	if v, ok := os.LookupEnv("TYPESENSE_URL"); ok {
+		err := cfg.set("url", v)
+		if err != nil {
+			return errors.Wrap(err, "TYPESENSE_URL")
+		}
+	}
+
+	for _, key := range configKeys {
+		v, ok := os.LookupEnv("TYPESENSE_" + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+
+		err := cfg.set(key, v)
+		if err != nil {
+			return errors.Wrapf(err, "TYPESENSE_%v", strings.ToUpper(key))
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads the configuration from a JSON file with named profiles.
+// TOML files aren't supported, and files with a .toml extension return an error.
+//
+// If profile is empty, the file's default_profile is used, or the profile named "default" if that isn't set.
+// Files without a profiles object are read as a single profile.
+//
+//	{
+//		"default_profile": "local",
+//		"profiles": {
+//			"local": {"nodes": ["http://localhost:8108"], "api_key": "xyz"},
+//			"prod": {
+//				"nodes": ["https://ts-1.example.com", "https://ts-2.example.com"],
+//				"api_key": "...",
+//				"timeout": "5s",
+//				"max_retries": 2
+//			}
+//		}
+//	}
+//
+// Settings use the same names as the environment variables read by ConfigFromEnv, in lower case and without the TYPESENSE_ prefix.
+// Durations are strings, such as "5s". Unknown settings are an error.
+func LoadConfig(path, profile string) (cfg Config, err error) {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return cfg, errors.Errorf("reading %v: TOML config files aren't supported, use JSON", path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	var file configFile
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&file)
+	if err != nil {
+		return cfg, errors.Wrapf(err, "reading %v", path)
+	}
+
+	settings := file.configProfile
+	if len(file.Profiles) > 0 {
+		name := profile
+		if name == "" {
+			name = file.DefaultProfile
+		}
+		if name == "" {
+			name = "default"
+		}
+
+		p, ok := file.Profiles[name]
+		if !ok {
+			return cfg, errors.Errorf("profile %q not found in %v", name, path)
+		}
+		settings = p
+	} else if profile != "" {
+		return cfg, errors.Errorf("profile %q not found in %v", profile, path)
+	}
+
+	cfg, err = settings.config()
+	return cfg, errors.Wrapf(err, "reading %v", path)
+}
+
+// configFile is the format of a config file.
+// The settings at the top level are only used if the file has no profiles.
+type configFile struct {
+	DefaultProfile string                   `json:"default_profile"`
+	Profiles       map[string]configProfile `json:"profiles"`
+
+	configProfile
+}
+
+type configProfile struct {
+	Nodes        []string `json:"nodes"`
+	APIKey       string   `json:"api_key"`
+	Timeout      string   `json:"timeout"`
+	MaxRetries   int      `json:"max_retries"`
+	RetryBackoff string   `json:"retry_backoff"`
+	UserAgent    string   `json:"user_agent"`
+
+	TLSCAFile             string `json:"tls_ca_file"`
+	TLSCertFile           string `json:"tls_cert_file"`
+	TLSKeyFile            string `json:"tls_key_file"`
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify"`
+
+	Lazy bool `json:"lazy"`
+}
+
+func (p configProfile) config() (cfg Config, err error) {
+	cfg = Config{
+		Nodes:      p.Nodes,
+		APIKey:     p.APIKey,
+		MaxRetries: p.MaxRetries,
+		UserAgent:  p.UserAgent,
+		TLS: TLSConfig{
+			CAFile:             p.TLSCAFile,
+			CertFile:           p.TLSCertFile,
+			KeyFile:            p.TLSKeyFile,
+			InsecureSkipVerify: p.TLSInsecureSkipVerify,
+		},
+		Lazy: p.Lazy,
+	}
+
+	if p.Timeout != "" {
+		cfg.Timeout, err = time.ParseDuration(p.Timeout)
+		if err != nil {
+			return cfg, errors.Wrap(err, "invalid value for timeout")
+		}
+	}
+
+	if p.RetryBackoff != "" {
+		cfg.RetryBackoff, err = time.ParseDuration(p.RetryBackoff)
+		if err != nil {
+			return cfg, errors.Wrap(err, "invalid value for retry_backoff")
+		}
+	}
+
+	return cfg, nil
+}
+
+// NewFromEnv creates a new Client configured from environment variables, and pings the server unless TYPESENSE_LAZY is set.
+// See ConfigFromEnv for the environment variables used.
+func NewFromEnv() (*Client, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConfig(cfg)
+}
+
+// NewFromConfig creates a new Client from cfg, and pings the server unless cfg.Lazy is set.
+func NewFromConfig(cfg Config) (*Client, error) {
+	c, err := newClient(cfg.Nodes, cfg.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Client.Timeout = cfg.Timeout
+	c.MaxRetries = cfg.MaxRetries
+	c.RetryBackoff = cfg.RetryBackoff
+	if cfg.UserAgent != "" {
+		c.UserAgent = cfg.UserAgent
+	}
+
+	if cfg.TLS != (TLSConfig{}) {
+		tlsConfig, err := cfg.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = tlsConfig
+		c.Client.Transport = t
+	}
+
+	if cfg.Lazy {
+		return c, nil
+	}
+
+	_, err = c.Health()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t TLSConfig) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading CA file")
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in %v", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,270 @@
+package tsclient_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+const profilesFile = `{
+	"default_profile": "local",
+	"profiles": {
+		"local": {"nodes": ["http://localhost:8108"], "api_key": "xyz"},
+		"prod": {
+			"nodes": ["https://ts-1.example.com", "https://ts-2.example.com/a,b"],
+			"api_key": "prod-key",
+			"timeout": "5s",
+			"max_retries": 2,
+			"retry_backoff": "250ms",
+			"tls_insecure_skip_verify": true
+		}
+	}
+}`
+
+func TestConfigFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	profiles := file("profiles.json", profilesFile)
+	single := file("single.json", `{"nodes": ["http://single:8108"], "api_key": "single-key", "lazy": true}`)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    tsclient.Config
+		wantErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "env only",
+			env: map[string]string{
+				"TYPESENSE_NODES":       "http://a:8108, http://b:8108",
+				"TYPESENSE_API_KEY":     "key",
+				"TYPESENSE_TIMEOUT":     "5s",
+				"TYPESENSE_MAX_RETRIES": "3",
+				"TYPESENSE_LAZY":        "true",
+			},
+			want: tsclient.Config{
+				Nodes:      []string{"http://a:8108", "http://b:8108"},
+				APIKey:     "key",
+				Timeout:    5 * time.Second,
+				MaxRetries: 3,
+				Lazy:       true,
+			},
+		},
+		{
+			name: "url alias",
+			env:  map[string]string{"TYPESENSE_URL": "http://a:8108"},
+			want: tsclient.Config{Nodes: []string{"http://a:8108"}},
+		},
+		{
+			name: "default profile",
+			env:  map[string]string{"TYPESENSE_CONFIG": profiles},
+			want: tsclient.Config{Nodes: []string{"http://localhost:8108"}, APIKey: "xyz"},
+		},
+		{
+			name: "named profile",
+			env:  map[string]string{"TYPESENSE_CONFIG": profiles, "TYPESENSE_PROFILE": "prod"},
+			want: tsclient.Config{
+				// array elements aren't split on commas
+				Nodes:        []string{"https://ts-1.example.com", "https://ts-2.example.com/a,b"},
+				APIKey:       "prod-key",
+				Timeout:      5 * time.Second,
+				MaxRetries:   2,
+				RetryBackoff: 250 * time.Millisecond,
+				TLS:          tsclient.TLSConfig{InsecureSkipVerify: true},
+			},
+		},
+		{
+			name: "env overrides profile",
+			env: map[string]string{
+				"TYPESENSE_CONFIG":      profiles,
+				"TYPESENSE_PROFILE":     "prod",
+				"TYPESENSE_API_KEY":     "env-key",
+				"TYPESENSE_MAX_RETRIES": "0",
+			},
+			want: tsclient.Config{
+				Nodes:        []string{"https://ts-1.example.com", "https://ts-2.example.com/a,b"},
+				APIKey:       "env-key",
+				Timeout:      5 * time.Second,
+				RetryBackoff: 250 * time.Millisecond,
+				TLS:          tsclient.TLSConfig{InsecureSkipVerify: true},
+			},
+		},
+		{
+			name: "single profile file",
+			env:  map[string]string{"TYPESENSE_CONFIG": single},
+			want: tsclient.Config{Nodes: []string{"http://single:8108"}, APIKey: "single-key", Lazy: true},
+		},
+		{
+			name:    "missing profile",
+			env:     map[string]string{"TYPESENSE_CONFIG": profiles, "TYPESENSE_PROFILE": "staging"},
+			wantErr: `profile "staging" not found`,
+		},
+		{
+			name:    "profile in file without profiles",
+			env:     map[string]string{"TYPESENSE_CONFIG": single, "TYPESENSE_PROFILE": "prod"},
+			wantErr: `profile "prod" not found`,
+		},
+		{
+			name:    "profile without file",
+			env:     map[string]string{"TYPESENSE_PROFILE": "prod"},
+			wantErr: "TYPESENSE_CONFIG is not set",
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"TYPESENSE_CONFIG": filepath.Join(dir, "missing.json")},
+			wantErr: "no such file",
+		},
+		{
+			name:    "malformed JSON",
+			env:     map[string]string{"TYPESENSE_CONFIG": file("malformed.json", `{"nodes": [`)},
+			wantErr: "reading",
+		},
+		{
+			name:    "unknown setting",
+			env:     map[string]string{"TYPESENSE_CONFIG": file("unknown.json", `{"node": "http://a:8108"}`)},
+			wantErr: `unknown field "node"`,
+		},
+		{
+			name:    "wrong type",
+			env:     map[string]string{"TYPESENSE_CONFIG": file("type.json", `{"max_retries": "2"}`)},
+			wantErr: "max_retries",
+		},
+		{
+			name:    "invalid duration in file",
+			env:     map[string]string{"TYPESENSE_CONFIG": file("duration.json", `{"timeout": "5 parsecs"}`)},
+			wantErr: "invalid value for timeout",
+		},
+		{
+			name:    "toml file",
+			env:     map[string]string{"TYPESENSE_CONFIG": file("config.toml", `nodes = ["http://a:8108"]`)},
+			wantErr: "TOML config files aren't supported",
+		},
+		{
+			name:    "invalid env value",
+			env:     map[string]string{"TYPESENSE_MAX_RETRIES": "many"},
+			wantErr: "TYPESENSE_MAX_RETRIES",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			setenv(t, test.env)
+
+			cfg, err := tsclient.ConfigFromEnv()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(cfg, test.want) {
+				t.Errorf("got %+v\nwant %+v", cfg, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	srv := tstest.NewServer()
+	defer srv.Close()
+
+	closed := tstest.NewServer()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		cfg     tsclient.Config
+		wantErr bool
+	}{
+		{
+			name: "reachable",
+			cfg:  tsclient.Config{Nodes: []string{srv.URL}, APIKey: tstest.APIKey, MaxRetries: 2, UserAgent: "test"},
+		},
+		{
+			name:    "unreachable",
+			cfg:     tsclient.Config{Nodes: []string{closed.URL}, APIKey: tstest.APIKey},
+			wantErr: true,
+		},
+		{
+			name: "unreachable lazy",
+			cfg:  tsclient.Config{Nodes: []string{closed.URL}, APIKey: tstest.APIKey, Lazy: true},
+		},
+		{
+			name:    "no nodes",
+			cfg:     tsclient.Config{APIKey: tstest.APIKey, Lazy: true},
+			wantErr: true,
+		},
+		{
+			name:    "missing CA file",
+			cfg:     tsclient.Config{Nodes: []string{srv.URL}, TLS: tsclient.TLSConfig{CAFile: filepath.Join(t.TempDir(), "ca.pem")}, Lazy: true},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c, err := tsclient.NewFromConfig(test.cfg)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.MaxRetries != test.cfg.MaxRetries {
+				t.Errorf("MaxRetries = %v, want %v", c.MaxRetries, test.cfg.MaxRetries)
+			}
+			if test.cfg.UserAgent != "" && c.UserAgent != test.cfg.UserAgent {
+				t.Errorf("UserAgent = %q, want %q", c.UserAgent, test.cfg.UserAgent)
+			}
+		})
+	}
+}
+
+// setenv replaces all TYPESENSE_* environment variables with env until the test finishes.
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "TYPESENSE_") {
+			continue
+		}
+
+		i := strings.Index(kv, "=")
+		k, v := kv[:i], kv[i+1:]
+		os.Unsetenv(k)
+		t.Cleanup(func() { os.Setenv(k, v) })
+	}
+
+	for k, v := range env {
+		k := k
+		os.Setenv(k, v)
+		t.Cleanup(func() { os.Unsetenv(k) })
+	}
+}
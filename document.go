@@ -3,12 +3,17 @@ package tsclient
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 
 	"emperror.dev/errors"
+
+	"github.com/termora/tsclient/utils/jsonutil"
 )
 
 // ErrNotSlice is returned by Import if a type other than a slice is given as input.
@@ -17,7 +22,13 @@ const ErrNotSlice = errors.Sentinel("slice expected")
 // Insert inserts a document into the collection.
 // The inserted document is unmarshaled to `out` if it is not nil.
 func (c *Client) Insert(collection string, doc interface{}, out interface{}) (err error) {
-	resp, err := c.Request("POST", "/collections/"+collection+"/documents", WithJSONBody(doc))
+	endpoint, err := collectionPath(collection, "documents")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Request("POST", endpoint, WithJSONBody(doc))
+	c.cache.invalidate(collection)
 	if err != nil || out == nil {
 		return
 	}
@@ -28,10 +39,16 @@ func (c *Client) Insert(collection string, doc interface{}, out interface{}) (er
 // Upsert inserts a document into the collection, updating it if it already exists.
 // The inserted document is unmarshaled to `out` if it is not nil.
 func (c *Client) Upsert(collection string, doc interface{}, out interface{}) (err error) {
-	resp, err := c.Request("POST", "/collections/"+collection+"/documents",
+	endpoint, err := collectionPath(collection, "documents")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Request("POST", endpoint,
 		WithJSONBody(doc),
 		WithURLValues(url.Values{"action": {"upsert"}}),
 	)
+	c.cache.invalidate(collection)
 	if err != nil || out == nil {
 		return
 	}
@@ -40,10 +57,25 @@ func (c *Client) Upsert(collection string, doc interface{}, out interface{}) (er
 }
 
 type importResponse struct {
-	Success bool `json:"success"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error"`
+	Document string `json:"document"`
+}
+
+// ImportError is returned for a single document that was rejected during an import.
+type ImportError struct {
+	// The error message returned by Typesense.
+	Message string
+	// The rejected document, as sent to Typesense.
+	Document string
+}
+
+func (e *ImportError) Error() string {
+	return "import failed: " + e.Message
 }
 
 // Import imports the documents into the collection.
+// action is optional, and Typesense's default action (create) is used if it is empty.
 // It returns an error if s is not a slice.
 func (c *Client) Import(collection, action string, s interface{}) (ok []bool, err error) {
 	slice := []interface{}{}
@@ -62,6 +94,7 @@ func (c *Client) Import(collection, action string, s interface{}) (ok []bool, er
 }
 
 // ImportSlice imports a slice of documents into the collection.
+// action is optional, and Typesense's default action (create) is used if it is empty.
 func (c *Client) ImportSlice(collection, action string, s []interface{}) (ok []bool, err error) {
 	b := new(bytes.Buffer)
 
@@ -74,12 +107,37 @@ func (c *Client) ImportSlice(collection, action string, s []interface{}) (ok []b
 		}
 	}
 
-	resp, err := c.Request("POST", "/collections/"+collection+"/documents/import",
-		WithBody(b),
+	res, err := c.importJSONL(collection, action, b)
+	if err != nil {
+		return
+	}
+
+	for _, r := range res {
+		ok = append(ok, r.Success)
+	}
+	return
+}
+
+// importJSONL imports newline-delimited JSON documents into the collection.
+// action is optional and may be left empty.
+func (c *Client) importJSONL(collection, action string, body io.Reader) (res []importResponse, err error) {
+	opts := []RequestOption{
+		WithBody(body),
 		WithHeader(http.Header{
 			"Content-Type": {"application/json"},
 		}),
-	)
+	}
+	if action != "" {
+		opts = append(opts, WithURLValues(url.Values{"action": {action}}))
+	}
+
+	endpoint, err := collectionPath(collection, "documents", "import")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Request("POST", endpoint, opts...)
+	c.cache.invalidate(collection)
 	if err != nil {
 		return
 	}
@@ -93,7 +151,7 @@ func (c *Client) ImportSlice(collection, action string, s []interface{}) (ok []b
 			return
 		}
 
-		ok = append(ok, r.Success)
+		res = append(res, r)
 	}
 	return
 }
@@ -101,7 +159,12 @@ func (c *Client) ImportSlice(collection, action string, s []interface{}) (ok []b
 // Document retrieves a document from the collection by ID.
 // The document is unmarshaled to `out` if it is not nil.
 func (c *Client) Document(collection, id string, out interface{}) (string, error) {
-	resp, err := c.Request("GET", "/collections/"+collection+"/documents/"+id)
+	endpoint, err := documentPath(collection, id)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Request("GET", endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -122,11 +185,225 @@ func (c *Client) Document(collection, id string, out interface{}) (string, error
 	return s.ID, json.Unmarshal(resp, out)
 }
 
+const (
+	// Typesense's maximum per_page.
+	documentsPerSearch = 250
+	// Maximum length of the escaped filter in a Documents search.
+	// Typesense limits the query string of GET searches to 4000 characters.
+	documentsFilterLength = 3000
+	// Maximum number of concurrent requests for documents that can't be fetched with a search.
+	documentsFetchConcurrency = 8
+)
+
+// filterOverhead is the length of the escaped filter without any IDs.
+var filterOverhead = len(url.QueryEscape("id:[]"))
+
+// Documents retrieves the documents with the given IDs from the collection.
+// Documents are fetched with as few searches as possible, filtering by ID, instead of one request per document.
+//
+// IDs containing a backtick can't be used in a filter, so those documents are fetched one by one,
+// with up to 8 requests at a time.
+//
+// The found documents are unmarshaled to out, which must be a pointer to a slice, in the order of ids.
+// The IDs of documents that don't exist are returned in missing, and are left out of out.
+// out may be nil to only check which documents exist.
+func (c *Client) Documents(collection string, ids []string, out interface{}) (missing []string, err error) {
+	if collection == "" {
+		return nil, ErrEmptyCollection
+	}
+
+	var slice reflect.Value
+	if out != nil {
+		val := reflect.ValueOf(out)
+		if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+			return nil, ErrNotSlice
+		}
+		slice = val.Elem()
+	}
+
+	docs := make(map[string]jsonutil.Raw, len(ids))
+
+	var (
+		seen  = make(map[string]bool, len(ids))
+		chunk []string
+		// length of the escaped filter, including the id:[] around the IDs
+		length = filterOverhead
+		// IDs that can't be used in a filter
+		single []string
+	)
+	for _, id := range ids {
+		if id == "" {
+			return nil, ErrEmptyID
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		// filter_by can't quote IDs containing backticks, so those are fetched on their own
+		if strings.Contains(id, "`") {
+			single = append(single, id)
+			continue
+		}
+
+		n := len(url.QueryEscape("`" + id + "`,"))
+		if len(chunk) > 0 && (len(chunk) == documentsPerSearch || length+n > documentsFilterLength) {
+			err = c.searchDocuments(collection, chunk, docs)
+			if err != nil {
+				return nil, err
+			}
+			chunk, length = nil, filterOverhead
+		}
+		chunk = append(chunk, id)
+		length += n
+	}
+
+	if len(chunk) > 0 {
+		err = c.searchDocuments(collection, chunk, docs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.fetchDocuments(collection, single, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	var found reflect.Value
+	if out != nil {
+		found = reflect.MakeSlice(slice.Type(), 0, len(ids))
+	}
+
+	for _, id := range ids {
+		doc, ok := docs[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if out == nil {
+			continue
+		}
+
+		v := reflect.New(slice.Type().Elem())
+		err = doc.UnmarshalTo(v.Interface())
+		if err != nil {
+			return nil, errors.Wrapf(err, "document %q", id)
+		}
+		found = reflect.Append(found, v.Elem())
+	}
+
+	if out != nil {
+		slice.Set(found)
+	}
+	return missing, nil
+}
+
+// searchDocuments fetches the documents with the given IDs with a single search, adding them to docs.
+func (c *Client) searchDocuments(collection string, ids []string, docs map[string]jsonutil.Raw) error {
+	endpoint, err := collectionPath(collection, "documents", "search")
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = "`" + id + "`"
+	}
+
+	data := SearchData{
+		Query:            "*",
+		FilterBy:         "id:[" + strings.Join(quoted, ",") + "]",
+		PerPage:          len(ids),
+		DisableOverrides: true,
+	}
+
+	// not made with Search, which returns 400 errors as a successful response
+	// and would report every document as missing
+	info, err := c.do("GET", endpoint, false, WithURLValues(data.values()))
+	if err != nil {
+		return err
+	}
+
+	var res SearchResult
+	err = json.Unmarshal(info.Body, &res)
+	if err != nil {
+		return err
+	}
+
+	for _, hit := range res.Hits {
+		s := struct {
+			ID string `json:"id"`
+		}{}
+
+		err = hit.UnmarshalTo(&s)
+		if err != nil {
+			return err
+		}
+		docs[s.ID] = hit.Document
+	}
+	return nil
+}
+
+// fetchDocuments fetches the documents with the given IDs one by one, adding the ones that exist to docs.
+// Up to documentsFetchConcurrency documents are fetched at a time.
+func (c *Client) fetchDocuments(collection string, ids []string, docs map[string]jsonutil.Raw) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, documentsFetchConcurrency)
+	)
+
+	for _, id := range ids {
+		sem <- struct{}{}
+
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			var doc jsonutil.Raw
+			_, err := c.Document(collection, id, &doc)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrNotFound):
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+				}
+			default:
+				docs[id] = doc
+			}
+		}(id)
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
 // UpdateDocument updates a document in the collection by ID.
 // The updated document is unmarshaled to `out` if it is not nil.
 func (c *Client) UpdateDocument(collection, id string, doc, out interface{}) error {
-	resp, err := c.Request("PATCH", "/collections/"+collection+"/documents/"+id,
-		WithJSONBody(doc))
+	endpoint, err := documentPath(collection, id)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Request("PATCH", endpoint, WithJSONBody(doc))
+	c.cache.invalidate(collection)
 	if err != nil || out == nil {
 		return err
 	}
@@ -134,10 +411,50 @@ func (c *Client) UpdateDocument(collection, id string, doc, out interface{}) err
 	return json.Unmarshal(resp, out)
 }
 
+// UpdateQuery updates all documents in the collection matching filter with the fields in doc.
+// Returns the number of updated documents.
+// An invalid filter returns ErrBadRequest, with the message returned by Typesense.
+func (c *Client) UpdateQuery(collection, filter string, doc interface{}) (updated int, err error) {
+	endpoint, err := collectionPath(collection, "documents")
+	if err != nil {
+		return
+	}
+
+	// not made with Request, which returns 400 errors as a successful response,
+	// and would report an invalid filter as matching no documents
+	info, err := c.do("PATCH", endpoint, false,
+		WithURLValues(url.Values{"filter_by": {filter}}), WithJSONBody(doc))
+	c.cache.invalidate(collection)
+	if err != nil {
+		if errors.Is(err, ErrBadRequest) {
+			err = withMessage(err, info.Body)
+		}
+		return
+	}
+	resp := info.Body
+
+	s := struct {
+		NumUpdated int `json:"num_updated"`
+	}{}
+
+	err = json.Unmarshal(resp, &s)
+	if err != nil {
+		return
+	}
+
+	return s.NumUpdated, nil
+}
+
 // DeleteDocument deletes a document in the collection.
 // The deleted document is unmarshaled to `out` if it is not nil.
 func (c *Client) DeleteDocument(collection, id string, out interface{}) error {
-	resp, err := c.Request("DELETE", "/collections/"+collection+"/documents/"+id)
+	endpoint, err := documentPath(collection, id)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Request("DELETE", endpoint)
+	c.cache.invalidate(collection)
 	if err != nil || out == nil {
 		return err
 	}
@@ -148,13 +465,19 @@ func (c *Client) DeleteDocument(collection, id string, out interface{}) error {
 // DeleteQuery deletes documents in the collection matching filter.
 // Returns the number of deleted documents.
 func (c *Client) DeleteQuery(collection, filter string, batchSize int) (deleted int, err error) {
+	endpoint, err := collectionPath(collection, "documents")
+	if err != nil {
+		return
+	}
+
 	v := url.Values{"filter_by": {filter}}
 
 	if batchSize != 0 {
 		v["batch_size"] = []string{strconv.Itoa(batchSize)}
 	}
 
-	resp, err := c.Request("DELETE", "/collections/"+collection+"/documents", WithURLValues(v))
+	resp, err := c.Request("DELETE", endpoint, WithURLValues(v))
+	c.cache.invalidate(collection)
 	if err != nil {
 		return
 	}
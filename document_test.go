@@ -0,0 +1,273 @@
+package tsclient_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+// documentRequests records the searches and single document requests made by Documents.
+type documentRequests struct {
+	mu       sync.Mutex
+	searches []url.Values
+	gets     int
+}
+
+func (r *documentRequests) hook(info *tsclient.RequestInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(info.Endpoint, "/documents/search"):
+		r.searches = append(r.searches, info.Request.URL.Query())
+	case info.Method == "GET" && strings.Contains(info.Endpoint, "/documents/"):
+		r.gets++
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	// about 14 of these fit in a single filter
+	long := make([]string, 20)
+	reversed := make([]string, len(long))
+	for i := range long {
+		long[i] = strconv.Itoa(i) + "-" + strings.Repeat("x", 200)
+		reversed[len(long)-1-i] = long[i]
+	}
+
+	tests := []struct {
+		name string
+		// IDs of the documents in the collection
+		stored []string
+		// IDs passed to Documents
+		ids          []string
+		wantMissing  []string
+		wantSearches int
+		wantGets     int
+	}{
+		{
+			name:         "chunked by count",
+			stored:       ids(0, 600),
+			ids:          append(ids(0, 600), "missing"),
+			wantMissing:  []string{"missing"},
+			wantSearches: 3,
+		},
+		{
+			name:         "chunked by filter length",
+			stored:       long,
+			ids:          reversed,
+			wantSearches: 2,
+		},
+		{
+			name:         "duplicates and order",
+			stored:       ids(0, 5),
+			ids:          []string{"3", "1", "missing", "3", "0"},
+			wantMissing:  []string{"missing"},
+			wantSearches: 1,
+		},
+		{
+			name:         "backticks",
+			stored:       append(ids(0, 20), "a`b", "`c", "d`"),
+			ids:          append(ids(0, 20), "a`b", "`c", "d`", "e`"),
+			wantMissing:  []string{"e`"},
+			wantSearches: 1,
+			wantGets:     4,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := tstest.New(t)
+
+			_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs := make([]bulkDoc, len(test.stored))
+			for i, id := range test.stored {
+				docs[i] = bulkDoc{ID: id, Name: "doc " + id}
+			}
+			_, err = c.Import("docs", "create", docs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var reqs documentRequests
+			c.Use(tsclient.Hooks(reqs.hook, nil))
+
+			var out []bulkDoc
+			missing, err := c.Documents("docs", test.ids, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(missing, test.wantMissing) {
+				t.Errorf("got missing %q, want %q", missing, test.wantMissing)
+			}
+
+			var want []bulkDoc
+			for _, id := range test.ids {
+				if !contains(test.wantMissing, id) {
+					want = append(want, bulkDoc{ID: id, Name: "doc " + id})
+				}
+			}
+			if !reflect.DeepEqual(out, want) {
+				t.Errorf("got %v documents, want %v in the order of ids", len(out), len(want))
+			}
+
+			if len(reqs.searches) != test.wantSearches {
+				t.Errorf("made %v searches, want %v", len(reqs.searches), test.wantSearches)
+			}
+			if reqs.gets != test.wantGets {
+				t.Errorf("made %v single document requests, want %v", reqs.gets, test.wantGets)
+			}
+
+			for _, q := range reqs.searches {
+				if _, ok := q["query_by"]; ok {
+					t.Errorf("search has a query_by parameter: %q", q.Get("query_by"))
+				}
+				if n := len(url.QueryEscape(q.Get("filter_by"))); n > 3000 {
+					t.Errorf("escaped filter is %v characters long, want at most 3000", n)
+				}
+				if n := strings.Count(q.Get("filter_by"), ","); n >= 250 {
+					t.Errorf("search is for %v IDs, want at most 250", n+1)
+				}
+			}
+		})
+	}
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDocumentsBacktickConcurrency(t *testing.T) {
+	c, _ := tstest.New(t)
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for i := 0; i < 50; i++ {
+		ids = append(ids, "`"+strconv.Itoa(i))
+	}
+
+	var (
+		mu             sync.Mutex
+		inFlight, most int
+		singleRequests int
+	)
+	c.Use(tsclient.Hooks(func(info *tsclient.RequestInfo) {
+		mu.Lock()
+		inFlight++
+		singleRequests++
+		if inFlight > most {
+			most = inFlight
+		}
+		mu.Unlock()
+	}, func(info *tsclient.RequestInfo) {
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+
+	missing, err := c.Documents("docs", ids, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != len(ids) {
+		t.Errorf("got %v missing documents, want %v", len(missing), len(ids))
+	}
+
+	if singleRequests != len(ids) {
+		t.Errorf("made %v requests, want %v", singleRequests, len(ids))
+	}
+	if most > 8 {
+		t.Errorf("made up to %v requests at a time, want at most 8", most)
+	}
+}
+
+func TestUpdateQuery(t *testing.T) {
+	c, _ := tstest.New(t)
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Import("docs", "create", []bulkDoc{{ID: "1", Name: "a"}, {ID: "2", Name: "a"}, {ID: "3", Name: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := c.UpdateQuery("docs", "name:=a", map[string]string{"name": "c"})
+	if err != nil || n != 2 {
+		t.Errorf("got %v, %v, want 2 updated documents", n, err)
+	}
+
+	n, err = c.UpdateQuery("docs", "name:=missing", map[string]string{"name": "c"})
+	if err != nil || n != 0 {
+		t.Errorf("got %v, %v for a filter matching nothing, want 0, nil", n, err)
+	}
+
+	// an invalid filter is an error, not a filter matching nothing
+	n, err = c.UpdateQuery("docs", "not a filter", map[string]string{"name": "c"})
+	if !errors.Is(err, tsclient.ErrBadRequest) {
+		t.Errorf("got %v, %v for an invalid filter, want ErrBadRequest", n, err)
+	}
+	if err != nil && err.Error() == tsclient.ErrBadRequest.Error() {
+		t.Errorf("error %q doesn't include the server's message", err)
+	}
+}
+
+func TestImportAction(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		actions []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		actions = append(actions, r.URL.Query())
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"success": true}`+"\n")
+	}))
+	defer srv.Close()
+
+	c := tsclient.NewLazy(srv.URL, "key")
+	for _, action := range []string{"", "upsert"} {
+		_, err := c.Import("docs", action, []bulkDoc{{ID: "1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := c.NewBulkIndexer(tsclient.BulkIndexerConfig{FlushInterval: -1})
+	_ = b.Add("docs", "", bulkDoc{ID: "1"})
+	b.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// the action is only sent if it is set, so Typesense's default is used otherwise
+	want := []url.Values{{}, {"action": {"upsert"}}, {}}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("got query strings %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,83 @@
+package tsclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// embeddingFields caches the names of each collection's embedding fields,
+// so they can be excluded from search results without looking up the schema for every search.
+type embeddingFields struct {
+	mu     sync.Mutex
+	fields map[string]*embeddingLookup
+}
+
+type embeddingLookup struct {
+	done   chan struct{}
+	fields []string
+	// zero if the lookup succeeded, as successful lookups are kept until the collection is invalidated
+	expires time.Time
+}
+
+// embeddingRetryInterval is how long a failed lookup is cached before the schema is looked up again.
+const embeddingRetryInterval = time.Minute
+
+func newEmbeddingFields() *embeddingFields {
+	return &embeddingFields{fields: map[string]*embeddingLookup{}}
+}
+
+// get returns the names of the collection's embedding fields, looking up its schema if they aren't cached.
+// Concurrent calls for the same collection share a single lookup.
+//
+// If the lookup fails, for example because the API key can't read the schema (such as search-only keys)
+// or because the collection name is an alias, no fields are returned.
+// Failed lookups are cached for embeddingRetryInterval, so searches don't look up the schema every time.
+func (ef *embeddingFields) get(ctx context.Context, c *Client, collection string) []string {
+	if ef == nil {
+		return nil
+	}
+
+	ef.mu.Lock()
+	lookup, ok := ef.fields[collection]
+	if ok && (lookup.expires.IsZero() || time.Now().Before(lookup.expires)) {
+		ef.mu.Unlock()
+
+		select {
+		case <-lookup.done:
+			return lookup.fields
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	lookup = &embeddingLookup{done: make(chan struct{})}
+	ef.fields[collection] = lookup
+	ef.mu.Unlock()
+
+	col, err := c.WithContext(ctx).Collection(collection)
+	if err == nil {
+		lookup.fields = col.EmbeddingFields()
+	} else {
+		c.log().Warn("looking up embedding fields failed, they won't be excluded from search results",
+			"collection", collection, "error", err)
+
+		ef.mu.Lock()
+		lookup.expires = time.Now().Add(embeddingRetryInterval)
+		ef.mu.Unlock()
+	}
+
+	close(lookup.done)
+	return lookup.fields
+}
+
+// invalidate removes the collection's cached embedding fields, so its schema is looked up again for the next search.
+func (ef *embeddingFields) invalidate(collection string) {
+	if ef == nil {
+		return
+	}
+
+	ef.mu.Lock()
+	delete(ef.fields, collection)
+	ef.mu.Unlock()
+}
@@ -0,0 +1,287 @@
+package tsclient_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+// embeddingServer returns a stand-in for an OpenAI-compatible embeddings API.
+// The embedding of a text is its length and number of words. Inputs are recorded in inputs.
+func embeddingServer(t *testing.T) (srv *httptest.Server, inputs func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer openai-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || req.Model != "text-embedding-3-small" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		seen = append(seen, req.Input)
+		mu.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"embedding": embedding(req.Input)},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func embedding(text string) []float64 {
+	return []float64{float64(len(text)), float64(len(strings.Fields(text)))}
+}
+
+type embeddedTerm struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Embedding []float64 `json:"embedding,omitempty"`
+}
+
+// embeddingCollection creates a collection with an embedding field generated by the stand-in server.
+func embeddingCollection(t *testing.T, c *tsclient.Client, embedURL string) {
+	t.Helper()
+
+	_, err := c.CreateCollection("terms", "", []tsclient.CreateFieldData{
+		{Name: "name", Type: "string"},
+		{Name: "embedding", Type: "float[]", Embed: &tsclient.FieldEmbed{
+			From: []string{"name"},
+			ModelConfig: tsclient.ModelConfig{
+				ModelName:      "openai/text-embedding-3-small",
+				APIKey:         "openai-key",
+				URL:            embedURL,
+				IndexingPrefix: "passage: ",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchExcludesEmbeddings(t *testing.T) {
+	embedSrv, inputs := embeddingServer(t)
+	c, _ := tstest.New(t)
+
+	var schemaRequests int32
+	c.Use(tsclient.Hooks(func(info *tsclient.RequestInfo) {
+		if info.Method == "GET" && info.Endpoint == "/collections/terms" {
+			atomic.AddInt32(&schemaRequests, 1)
+		}
+	}, nil))
+
+	embeddingCollection(t, c, embedSrv.URL)
+
+	err := c.Insert("terms", embeddedTerm{ID: "1", Name: "plural system"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := inputs(); !reflect.DeepEqual(got, []string{"passage: plural system"}) {
+		t.Errorf("embedding server got %q, want the prefixed name", got)
+	}
+	want := embedding("passage: plural system")
+
+	hit := func(data tsclient.SearchData) embeddedTerm {
+		t.Helper()
+
+		res, err := c.Search("terms", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != 1 {
+			t.Fatalf("got %v hits, want 1", len(res.Hits))
+		}
+
+		var term embeddedTerm
+		err = res.Hits[0].UnmarshalTo(&term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return term
+	}
+
+	if term := hit(tsclient.SearchData{Query: "plural", QueryBy: []string{"name"}}); term.Name != "plural system" || term.Embedding != nil {
+		t.Errorf("got %+v, want the document without its embedding", term)
+	}
+	// the ExcludeFields slice passed in isn't modified
+	exclude := make([]string, 1, 2)
+	exclude[0] = "id"
+	if term := hit(tsclient.SearchData{Query: "*", ExcludeFields: exclude}); term.ID != "" || term.Embedding != nil {
+		t.Errorf("got %+v, want the document without its ID and embedding", term)
+	}
+	if exclude[:2][1] != "" {
+		t.Errorf("ExcludeFields was modified: %q", exclude[:2])
+	}
+
+	if term := hit(tsclient.SearchData{Query: "*", IncludeEmbeddings: true}); !reflect.DeepEqual(term.Embedding, want) {
+		t.Errorf("got embedding %v, want %v", term.Embedding, want)
+	}
+	if term := hit(tsclient.SearchData{Query: "*", IncludeFields: []string{"embedding"}}); !reflect.DeepEqual(term.Embedding, want) {
+		t.Errorf("got embedding %v with the field included, want %v", term.Embedding, want)
+	}
+
+	// documents fetched by ID aren't affected
+	var doc embeddedTerm
+	_, err = c.Document("terms", "1", &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Embedding, want) {
+		t.Errorf("got embedding %v from Document, want %v", doc.Embedding, want)
+	}
+
+	// the schema is only looked up once
+	if n := atomic.LoadInt32(&schemaRequests); n != 1 {
+		t.Errorf("schema looked up %v times, want 1", n)
+	}
+
+	// until the collection is recreated
+	_, err = c.DeleteCollection("terms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.CreateCollection("terms", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}, {Name: "embedding", Type: "float[]", NumDim: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Insert("terms", embeddedTerm{ID: "1", Name: "plural system", Embedding: []float64{1, 2}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if term := hit(tsclient.SearchData{Query: "*"}); !reflect.DeepEqual(term.Embedding, []float64{1, 2}) {
+		t.Errorf("got embedding %v after recreating the collection without an embed field, want [1 2]", term.Embedding)
+	}
+	if n := atomic.LoadInt32(&schemaRequests); n != 2 {
+		t.Errorf("schema looked up %v times after recreating the collection, want 2", n)
+	}
+}
+
+func TestSearchEmbeddingsLookupFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"unauthorized", http.StatusUnauthorized},
+		// scoped search-only keys
+		{"forbidden", http.StatusForbidden},
+		// aliases
+		{"not found", http.StatusNotFound},
+		{"unavailable", http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				schemaRequests int32
+				mu             sync.Mutex
+				excluded       []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/collections/terms" {
+					atomic.AddInt32(&schemaRequests, 1)
+					w.WriteHeader(test.status)
+					_, _ = io.WriteString(w, `{"message": "no"}`)
+					return
+				}
+
+				mu.Lock()
+				excluded = append(excluded, r.URL.Query().Get("exclude_fields"))
+				mu.Unlock()
+				_, _ = io.WriteString(w, `{"found": 0, "hits": []}`)
+			}))
+			defer srv.Close()
+
+			c := tsclient.NewLazy(srv.URL, "search-only")
+
+			// searches still work, they just can't exclude embeddings
+			for i := 0; i < 3; i++ {
+				_, err := c.Search("terms", tsclient.SearchData{Query: "*", ExcludeFields: []string{"name"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(excluded, []string{"name", "name", "name"}) {
+				t.Errorf("got exclude_fields %q", excluded)
+			}
+			// the failure is cached
+			if n := atomic.LoadInt32(&schemaRequests); n != 1 {
+				t.Errorf("schema looked up %v times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestEmbeddingModelErrors(t *testing.T) {
+	embedSrv, _ := embeddingServer(t)
+	c, _ := tstest.New(t)
+
+	_, err := c.CreateCollection("terms", "", []tsclient.CreateFieldData{
+		{Name: "name", Type: "string"},
+		{Name: "embedding", Type: "float[]", Embed: &tsclient.FieldEmbed{
+			From: []string{"name"},
+			ModelConfig: tsclient.ModelConfig{
+				ModelName: "openai/text-embedding-3-small",
+				APIKey:    "wrong-key",
+				URL:       embedSrv.URL,
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Request returns 400 errors as a successful response, so check that nothing was inserted
+	_ = c.Insert("terms", embeddedTerm{ID: "1", Name: "plural"}, nil)
+	_, err = c.Document("terms", "1", nil)
+	if !errors.Is(err, tsclient.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound for a document whose embedding failed", err)
+	}
+
+	ok, err := c.Import("terms", "create", []embeddedTerm{{ID: "2", Name: "system"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ok) != 1 || ok[0] {
+		t.Errorf("import results %v, want a failure", ok)
+	}
+}
@@ -0,0 +1,126 @@
+package tsclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/termora/tsclient/utils/jsonutil"
+)
+
+// ExportData is used in (*Client).Export. All fields are optional.
+type ExportData struct {
+	// Filter conditions for the documents to export.
+	FilterBy string
+
+	// list of fields from the document to include in the export.
+	IncludeFields []string
+	// list of fields from the document to exclude in the export.
+	ExcludeFields []string
+}
+
+// Export exports documents in the collection.
+// The documents are streamed from the server and decoded one by one, so the collection doesn't need to fit in memory.
+// The returned iterator must be closed.
+func (c *Client) Export(collection string, data ExportData) (*ExportIterator, error) {
+	body, err := c.ExportReader(collection, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportIterator{
+		body: body,
+		dec:  json.NewDecoder(body),
+	}, nil
+}
+
+// ExportReader exports documents in the collection, returning the raw JSONL response body.
+// The returned reader must be closed.
+func (c *Client) ExportReader(collection string, data ExportData) (io.ReadCloser, error) {
+	endpoint, err := collectionPath(collection, "documents", "export")
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{}
+
+	if data.FilterBy != "" {
+		v["filter_by"] = []string{data.FilterBy}
+	}
+
+	if len(data.IncludeFields) > 0 {
+		v["include_fields"] = []string{strings.Join(data.IncludeFields, ",")}
+	}
+
+	if len(data.ExcludeFields) > 0 {
+		v["exclude_fields"] = []string{strings.Join(data.ExcludeFields, ",")}
+	}
+
+	return c.stream("GET", endpoint, WithURLValues(v))
+}
+
+// ExportIterator iterates over exported documents.
+// Successive calls to Next step through the documents, in the style of bufio.Scanner:
+//
+//	it, err := client.Export("terms", tsclient.ExportData{})
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//
+//	for it.Next() {
+//		var t Term
+//		err = it.Decode(&t)
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ExportIterator struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+
+	doc jsonutil.Raw
+	err error
+}
+
+// Next advances the iterator to the next document. It returns false when there are no more documents or an error occurred.
+func (it *ExportIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	var doc jsonutil.Raw
+	err := it.dec.Decode(&doc)
+	if err != nil {
+		if err != io.EOF {
+			it.err = err
+		}
+		it.doc = nil
+		return false
+	}
+
+	it.doc = doc
+	return true
+}
+
+// Document returns the raw JSON of the current document.
+func (it *ExportIterator) Document() jsonutil.Raw {
+	return it.doc
+}
+
+// Decode unmarshals the current document into v.
+func (it *ExportIterator) Decode(v interface{}) error {
+	return it.doc.UnmarshalTo(v)
+}
+
+// Err returns the first error encountered by the iterator, if any.
+func (it *ExportIterator) Err() error {
+	return it.err
+}
+
+// Close closes the underlying response body.
+func (it *ExportIterator) Close() error {
+	return it.body.Close()
+}
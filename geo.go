@@ -0,0 +1,116 @@
+package tsclient
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// GeoPoint is a point stored in a geopoint field.
+// It is encoded as a [lat, lng] array.
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// MarshalJSON encodes p as [lat, lng].
+func (p GeoPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{p.Lat, p.Lng})
+}
+
+// UnmarshalJSON decodes a [lat, lng] array into p. Like other types, null leaves p unchanged.
+func (p *GeoPoint) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var a []float64
+	err := json.Unmarshal(data, &a)
+	if err != nil {
+		return err
+	}
+
+	if len(a) != 2 {
+		return errors.New("geopoint must have exactly 2 coordinates")
+	}
+
+	p.Lat, p.Lng = a[0], a[1]
+	return nil
+}
+
+func (p GeoPoint) String() string {
+	return formatFloat(p.Lat) + ", " + formatFloat(p.Lng)
+}
+
+// GeoUnit is a unit of distance used in geo filters and sorts.
+type GeoUnit string
+
+// Units of distance supported by Typesense.
+const (
+	Kilometers GeoUnit = "km"
+	Miles      GeoUnit = "mi"
+)
+
+// GeoRadiusFilter returns a filter_by condition matching documents within radius of p.
+func GeoRadiusFilter(field string, p GeoPoint, radius float64, unit GeoUnit) string {
+	return field + ":(" + p.String() + ", " + formatFloat(radius) + " " + string(unit) + ")"
+}
+
+// GeoPolygonFilter returns a filter_by condition matching documents inside the polygon described by points.
+func GeoPolygonFilter(field string, points ...GeoPoint) string {
+	s := make([]string, len(points))
+	for i, p := range points {
+		s[i] = p.String()
+	}
+
+	return field + ":(" + strings.Join(s, ", ") + ")"
+}
+
+// GeoSort is a sort_by condition sorting documents by their distance to a point.
+type GeoSort struct {
+	Field string
+	Point GeoPoint
+
+	// Sort documents furthest away first.
+	Desc bool
+
+	// Documents within this radius of Point are treated as equally distant,
+	// so they can be sorted by another field.
+	ExcludeRadius float64
+	// Documents are grouped into buckets of this size, so documents within a bucket can be sorted by another field.
+	Precision float64
+	// The unit for ExcludeRadius and Precision.
+	// Default: Kilometers
+	Unit GeoUnit
+}
+
+// String returns the sort in the format used by Typesense's sort_by parameter.
+func (s GeoSort) String() string {
+	unit := s.Unit
+	if unit == "" {
+		unit = Kilometers
+	}
+
+	params := s.Point.String()
+
+	if s.ExcludeRadius != 0 {
+		params += ", exclude_radius: " + formatFloat(s.ExcludeRadius) + string(unit)
+	}
+
+	if s.Precision != 0 {
+		params += ", precision: " + formatFloat(s.Precision) + string(unit)
+	}
+
+	order := "asc"
+	if s.Desc {
+		order = "desc"
+	}
+
+	return s.Field + "(" + params + "):" + order
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,104 @@
+package tsclient_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/termora/tsclient"
+)
+
+func TestGeoPointJSON(t *testing.T) {
+	b, err := json.Marshal(tsclient.GeoPoint{Lat: 52.37, Lng: -4.9})
+	if err != nil || string(b) != "[52.37,-4.9]" {
+		t.Errorf("got %s, %v, want [52.37,-4.9]", b, err)
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		want    tsclient.GeoPoint
+		wantErr bool
+	}{
+		{"point", `[52.37, -4.9]`, tsclient.GeoPoint{Lat: 52.37, Lng: -4.9}, false},
+		{"null", `null`, tsclient.GeoPoint{Lat: 1, Lng: 2}, false},
+		{"one coordinate", `[52.37]`, tsclient.GeoPoint{Lat: 1, Lng: 2}, true},
+		{"three coordinates", `[1, 2, 3]`, tsclient.GeoPoint{Lat: 1, Lng: 2}, true},
+		{"empty", `[]`, tsclient.GeoPoint{Lat: 1, Lng: 2}, true},
+		{"object", `{"lat": 1, "lng": 2}`, tsclient.GeoPoint{Lat: 1, Lng: 2}, true},
+		{"string", `"52.37, -4.9"`, tsclient.GeoPoint{Lat: 1, Lng: 2}, true},
+	}
+
+	for _, test := range tests {
+		p := tsclient.GeoPoint{Lat: 1, Lng: 2}
+		err := json.Unmarshal([]byte(test.json), &p)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: got error %v, want error %v", test.name, err, test.wantErr)
+		}
+		if p != test.want {
+			t.Errorf("%v: got %+v, want %+v", test.name, p, test.want)
+		}
+	}
+
+	// null in a document leaves the field unset
+	var doc struct {
+		Location *tsclient.GeoPoint `json:"location"`
+		Home     tsclient.GeoPoint  `json:"home"`
+	}
+	err = json.Unmarshal([]byte(`{"location": null, "home": null}`), &doc)
+	if err != nil || doc.Location != nil || doc.Home != (tsclient.GeoPoint{}) {
+		t.Errorf("got %+v, %v for null fields", doc, err)
+	}
+}
+
+func TestGeoFilters(t *testing.T) {
+	amsterdam := tsclient.GeoPoint{Lat: 52.374, Lng: 4.8897}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"radius", tsclient.GeoRadiusFilter("location", amsterdam, 5.1, tsclient.Kilometers),
+			"location:(52.374, 4.8897, 5.1 km)"},
+		{"radius in miles", tsclient.GeoRadiusFilter("location", tsclient.GeoPoint{Lat: -33, Lng: 151}, 10, tsclient.Miles),
+			"location:(-33, 151, 10 mi)"},
+		{"polygon", tsclient.GeoPolygonFilter("location",
+			tsclient.GeoPoint{Lat: 48.8, Lng: 2.3}, tsclient.GeoPoint{Lat: 48.9, Lng: 2.3}, tsclient.GeoPoint{Lat: 48.9, Lng: 2.4}),
+			"location:(48.8, 2.3, 48.9, 2.3, 48.9, 2.4)"},
+		{"small coordinates", tsclient.GeoRadiusFilter("location", tsclient.GeoPoint{Lat: 0.000001, Lng: -0.5}, 0.25, tsclient.Kilometers),
+			"location:(0.000001, -0.5, 0.25 km)"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestGeoSort(t *testing.T) {
+	amsterdam := tsclient.GeoPoint{Lat: 52.374, Lng: 4.8897}
+
+	tests := []struct {
+		name string
+		sort tsclient.GeoSort
+		want string
+	}{
+		{"ascending", tsclient.GeoSort{Field: "location", Point: amsterdam},
+			"location(52.374, 4.8897):asc"},
+		{"descending", tsclient.GeoSort{Field: "location", Point: amsterdam, Desc: true},
+			"location(52.374, 4.8897):desc"},
+		{"exclude radius", tsclient.GeoSort{Field: "location", Point: amsterdam, ExcludeRadius: 2},
+			"location(52.374, 4.8897, exclude_radius: 2km):asc"},
+		{"precision in miles", tsclient.GeoSort{Field: "location", Point: amsterdam, Precision: 1.5, Unit: tsclient.Miles},
+			"location(52.374, 4.8897, precision: 1.5mi):asc"},
+		{"both", tsclient.GeoSort{Field: "location", Point: amsterdam, ExcludeRadius: 1, Precision: 3, Desc: true},
+			"location(52.374, 4.8897, exclude_radius: 1km, precision: 3km):desc"},
+	}
+
+	for _, test := range tests {
+		if got := test.sort.String(); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package tsclient
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgeConfig is the configuration for hedged requests. All fields are optional.
+type HedgeConfig struct {
+	// The percentile of recent latencies to wait for before sending a hedged request, between 0 and 100.
+	// Default: 95
+	Percentile float64
+	// The minimum time to wait before sending a hedged request.
+	// Default: 10 milliseconds
+	MinDelay time.Duration
+	// The time to wait before sending a hedged request until enough latencies have been recorded.
+	// Default: 100 milliseconds
+	InitialDelay time.Duration
+	// The number of recent latencies used to calculate the delay.
+	// Default: 200
+	Window int
+}
+
+// minHedgeSamples is the number of latencies needed before the percentile is used.
+const minHedgeSamples = 20
+
+// EnableHedging enables hedged requests for searches and retrieving documents by ID.
+// If a request hasn't completed after the configured percentile of recent latencies,
+// the same request is sent to another node, the first successful response is used, and the other request is canceled.
+// Hedging only has an effect on clients with multiple nodes.
+//
+// EnableHedging is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) EnableHedging(cfg HedgeConfig) {
+	if cfg.Percentile <= 0 || cfg.Percentile > 100 {
+		cfg.Percentile = 95
+	}
+	if cfg.MinDelay <= 0 {
+		cfg.MinDelay = 10 * time.Millisecond
+	}
+	if cfg.InitialDelay <= 0 {
+		cfg.InitialDelay = 100 * time.Millisecond
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 200
+	}
+
+	c.hedge = &hedger{
+		cfg:       cfg,
+		latencies: make([]time.Duration, 0, cfg.Window),
+	}
+}
+
+type hedger struct {
+	cfg HedgeConfig
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	// index of the oldest latency, once the window is full
+	pos int
+}
+
+// applies returns true if the request can be hedged.
+func (h *hedger) applies(info *RequestInfo) bool {
+	// every request needs its own copy of the body
+	if h == nil || info.stream || (info.Request.Body != nil && info.Request.GetBody == nil) {
+		return false
+	}
+
+	switch endpointFamily(info.Endpoint) {
+	case "search":
+		return isRead(info.Method, info.Endpoint)
+	case "documents":
+		return info.Method == "GET"
+	}
+	return false
+}
+
+// delay returns the time to wait before sending a hedged request.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	if len(h.latencies) < minHedgeSamples {
+		h.mu.Unlock()
+		return h.cfg.InitialDelay
+	}
+
+	sorted := append([]time.Duration(nil), h.latencies...)
+	h.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(math.Ceil(h.cfg.Percentile/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	d := sorted[i]
+	if d < h.cfg.MinDelay {
+		d = h.cfg.MinDelay
+	}
+	return d
+}
+
+func (h *hedger) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < h.cfg.Window {
+		h.latencies = append(h.latencies, d)
+		return
+	}
+
+	h.latencies[h.pos] = d
+	h.pos = (h.pos + 1) % h.cfg.Window
+}
+
+type hedgeResult struct {
+	info *RequestInfo
+	err  error
+}
+
+// sendHedged sends the request to a node, and to a second node if the first doesn't respond in time.
+// The first successful response is used.
+func (c *Client) sendHedged(info *RequestInfo, tried map[*node]bool) error {
+	parent := info.Request.Context()
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	start := func(nd *node, probe bool) error {
+		tried[nd] = true
+
+		ctx, cancel := context.WithCancel(parent)
+		cancels = append(cancels, cancel)
+
+		sub := &RequestInfo{
+			Method:   info.Method,
+			Endpoint: info.Endpoint,
+			Request:  info.Request.Clone(ctx),
+		}
+		if info.Request.GetBody != nil {
+			body, err := info.Request.GetBody()
+			if err != nil {
+				nd.breaker.release(probe)
+				return err
+			}
+			sub.Request.Body = body
+		}
+		info.countSent(sub.Request)
+
+		go func() {
+			t := time.Now()
+			err := c.sendTo(nd, sub)
+			nd.breaker.finish(ctx, sub, probe)
+			if err == nil {
+				c.hedge.observe(time.Since(t))
+			}
+			results <- hedgeResult{sub, err}
+		}()
+		return nil
+	}
+
+	nd, probe, err := c.nodes.pick(tried)
+	if err == nil {
+		err = start(nd, probe)
+	}
+	if err != nil {
+		info.Err = err
+		return err
+	}
+	pending := 1
+
+	timer := time.NewTimer(c.hedge.delay())
+	defer timer.Stop()
+
+	var last hedgeResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			nd, probe, err := c.nodes.pick(tried)
+			if err != nil {
+				continue
+			}
+			if tried[nd] {
+				// no other node is available
+				nd.breaker.release(probe)
+				continue
+			}
+
+			if start(nd, probe) != nil {
+				continue
+			}
+			c.log().Debug("hedging request", "method", info.Method, "endpoint", info.Endpoint, "node", nd.url)
+			info.Hedged = true
+			pending++
+
+		case res := <-results:
+			pending--
+			last = res
+			if res.err == nil {
+				pending = 0
+			}
+		}
+	}
+
+	info.StatusCode = last.info.StatusCode
+	info.Body = last.info.Body
+	info.Err = last.err
+	return last.err
+}
@@ -0,0 +1,130 @@
+package tsclient_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+// hedgeNodes returns a slow node, which only responds once its request is canceled or release is closed,
+// and a fast node, which responds immediately.
+func hedgeNodes(t *testing.T) (slow, fast *httptest.Server, canceled <-chan struct{}, release chan struct{}, fastRequests *int32) {
+	t.Helper()
+
+	cancelCh := make(chan struct{}, 1)
+	release = make(chan struct{})
+	slow = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			cancelCh <- struct{}{}
+		case <-release:
+			_, _ = io.WriteString(w, `{"id": "1", "node": "slow"}`)
+		}
+	}))
+	t.Cleanup(slow.Close)
+	// the slow handler must return before the server can be closed
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	var count int32
+	fast = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		_, _ = io.WriteString(w, `{"id": "1", "node": "fast"}`)
+	}))
+	t.Cleanup(fast.Close)
+
+	return slow, fast, cancelCh, release, &count
+}
+
+func TestHedging(t *testing.T) {
+	slow, fast, canceled, _, _ := hedgeNodes(t)
+
+	// round-robin starts at the first node, so the first request goes to the slow node
+	c, err := tsclient.NewNodesLazy([]string{slow.URL, fast.URL}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableHedging(tsclient.HedgeConfig{InitialDelay: 20 * time.Millisecond})
+
+	var hedged bool
+	c.Use(tsclient.Hooks(nil, func(info *tsclient.RequestInfo) { hedged = info.Hedged }))
+
+	body, err := c.Request("GET", "/collections/terms/documents/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"id": "1", "node": "fast"}` {
+		t.Errorf("got %s, want the fast node's response", body)
+	}
+	if !hedged {
+		t.Error("RequestInfo.Hedged is false")
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request to the slow node was not canceled")
+	}
+}
+
+func TestHedgingFastResponse(t *testing.T) {
+	slow, fast, _, release, fastRequests := hedgeNodes(t)
+
+	c, err := tsclient.NewNodesLazy([]string{slow.URL, fast.URL}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableHedging(tsclient.HedgeConfig{InitialDelay: time.Hour})
+
+	var hedged bool
+	c.Use(tsclient.Hooks(nil, func(info *tsclient.RequestInfo) { hedged = info.Hedged }))
+
+	// the first node responds before the hedging delay, so no hedged request is sent
+	close(release)
+	body, err := c.Request("GET", "/collections/terms/documents/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"id": "1", "node": "slow"}` {
+		t.Errorf("got %s, want the first node's response", body)
+	}
+	if hedged {
+		t.Error("RequestInfo.Hedged is true")
+	}
+	if n := atomic.LoadInt32(fastRequests); n != 0 {
+		t.Errorf("second node got %v requests, want 0", n)
+	}
+}
+
+func TestHedgingNotApplied(t *testing.T) {
+	slow, fast, _, release, fastRequests := hedgeNodes(t)
+
+	c, err := tsclient.NewNodesLazy([]string{slow.URL, fast.URL}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableHedging(tsclient.HedgeConfig{InitialDelay: time.Millisecond})
+
+	// writes are never hedged, however long they take
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	_, err = c.Request("PATCH", "/collections/terms/documents/1", tsclient.WithJSONBody(map[string]string{"name": "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(fastRequests); n != 0 {
+		t.Errorf("second node got %v requests, want 0", n)
+	}
+}
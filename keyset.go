@@ -0,0 +1,197 @@
+package tsclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// ErrInvalidCursor is returned by SearchAfter if the cursor is malformed or was created for a different sort field.
+const ErrInvalidCursor = errors.Sentinel("invalid search cursor")
+
+// ErrCursorID is returned by SearchAfter if a document ID needed in a cursor contains a backtick,
+// as it can't be used in a filter.
+const ErrCursorID = errors.Sentinel("document ID in search cursor contains a backtick")
+
+// Keyset is the sort field used to page through results with SearchAfter.
+type Keyset struct {
+	// A numerical field to sort by, for example a creation timestamp or sequential ID.
+	// Values don't have to be unique: documents sharing a value across a page boundary are excluded by ID on the next page,
+	// so cursors grow with the number of documents sharing the last value on a page.
+	Field string
+	// Sort in descending order.
+	Desc bool
+}
+
+type searchCursor struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d,omitempty"`
+	// the sort value of the last hit
+	Value json.Number `json:"v"`
+	// IDs of the hits returned so far with sort value Value
+	IDs []string `json:"i,omitempty"`
+}
+
+// SearchAfter searches the collection, returning the page of hits following the one described by cursor.
+// Unlike Search, it is not limited by the maximum of page * per_page, so it can be used to page through all matching documents.
+//
+// Results are sorted by key.Field only, and data.SortBy and data.Page are ignored.
+// Each page is fetched by adding conditions on key.Field and id to data.FilterBy.
+// Unlike Search, an invalid filter returns ErrBadRequest, with the message returned by Typesense.
+//
+// cursor should be empty for the first page. The returned cursor is empty if there are no more results.
+// Cursors are opaque, URL-safe strings, and can be handed to clients to continue paging later.
+func (c *Client) SearchAfter(collection string, data SearchData, key Keyset, cursor string) (res SearchResult, next string, err error) {
+	order, op := "asc", ">"
+	if key.Desc {
+		order, op = "desc", "<"
+	}
+
+	var cur searchCursor
+	if cursor != "" {
+		cur, err = decodeCursor(cursor)
+		if err != nil {
+			return res, "", err
+		}
+
+		if cur.Field != key.Field || cur.Desc != key.Desc {
+			return res, "", ErrInvalidCursor
+		}
+
+		// documents sharing the last value are included, except for the ones already returned
+		cond := key.Field + ":" + op + cur.Value.String()
+		if len(cur.IDs) > 0 {
+			quoted := make([]string, len(cur.IDs))
+			for i, id := range cur.IDs {
+				quoted[i] = "`" + id + "`"
+			}
+			cond = key.Field + ":" + op + "=" + cur.Value.String() + " && id:!=[" + strings.Join(quoted, ",") + "]"
+		}
+
+		if data.FilterBy != "" {
+			data.FilterBy = "(" + data.FilterBy + ") && " + cond
+		} else {
+			data.FilterBy = cond
+		}
+	}
+
+	data.SortBy = []string{key.Field + ":" + order}
+	data.Page = 1
+
+	resp, err := c.search(collection, data)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(resp, &res)
+	if err != nil {
+		return
+	}
+
+	perPage := data.PerPage
+	if perPage == 0 {
+		perPage = 10
+	}
+
+	if len(res.Hits) == 0 || len(res.Hits) < perPage || len(res.Hits) >= res.Found {
+		return res, "", nil
+	}
+
+	nextCur := searchCursor{Field: key.Field, Desc: key.Desc}
+	for _, hit := range res.Hits {
+		doc := map[string]json.RawMessage{}
+		err = hit.UnmarshalTo(&doc)
+		if err != nil {
+			return
+		}
+
+		var value json.Number
+		err = json.Unmarshal(doc[key.Field], &value)
+		if err != nil {
+			return res, "", errors.Wrapf(err, "reading sort field %q", key.Field)
+		}
+		value, err = normalizeNumber(value)
+		if err != nil {
+			return res, "", errors.Wrapf(err, "reading sort field %q", key.Field)
+		}
+
+		var id string
+		err = json.Unmarshal(doc["id"], &id)
+		if err != nil {
+			return res, "", errors.Wrap(err, "reading document ID")
+		}
+
+		if value != nextCur.Value {
+			nextCur.Value = value
+			nextCur.IDs = nil
+			// hits sharing the previous cursor's value were excluded from this page, but must stay excluded
+			if value == cur.Value {
+				nextCur.IDs = append(nextCur.IDs, cur.IDs...)
+			}
+		}
+		nextCur.IDs = append(nextCur.IDs, id)
+	}
+
+	for _, id := range nextCur.IDs {
+		if strings.Contains(id, "`") {
+			return res, "", ErrCursorID
+		}
+	}
+
+	next, err = encodeCursor(nextCur)
+	return res, next, err
+}
+
+func encodeCursor(cur searchCursor) (string, error) {
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s string) (cur searchCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(b, &cur)
+	if err != nil || cur.Field == "" {
+		return cur, ErrInvalidCursor
+	}
+
+	// only allow plain numbers, as the value is inserted into the filter
+	if normalized, err := normalizeNumber(cur.Value); err != nil || normalized != cur.Value {
+		return cur, ErrInvalidCursor
+	}
+
+	for _, id := range cur.IDs {
+		if id == "" || strings.Contains(id, "`") {
+			return cur, ErrInvalidCursor
+		}
+	}
+
+	return cur, nil
+}
+
+// plainNumber matches numbers without an exponent.
+var plainNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// normalizeNumber returns n without an exponent, so it can be used in a filter.
+func normalizeNumber(n json.Number) (json.Number, error) {
+	if plainNumber.MatchString(string(n)) {
+		return n, nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", errors.Errorf("invalid number %q", n)
+	}
+	return json.Number(strconv.FormatFloat(f, 'f', -1, 64)), nil
+}
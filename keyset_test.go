@@ -0,0 +1,178 @@
+package tsclient_test
+
+import (
+	"encoding/base64"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+type rankedDoc struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Rank int    `json:"rank"`
+}
+
+// rankedCollection creates a collection of 20 documents, most of which share a rank with others.
+func rankedCollection(t *testing.T, c *tsclient.Client) []rankedDoc {
+	t.Helper()
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{
+		{Name: "name", Type: "string"},
+		{Name: "rank", Type: "int32"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var docs []rankedDoc
+	for i := 0; i < 20; i++ {
+		// ranks 0 to 4, with rank 2 shared by 8 documents (more than a page)
+		rank := i / 4
+		if i >= 8 && i < 16 {
+			rank = 2
+		}
+		name := "even"
+		if i%2 == 1 {
+			name = "odd"
+		}
+		docs = append(docs, rankedDoc{ID: strconv.Itoa(i), Name: name, Rank: rank})
+	}
+	_, err = c.Import("docs", "create", docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return docs
+}
+
+func TestSearchAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		perPage int
+		desc    bool
+	}{
+		{"ascending", "", 3, false},
+		{"descending", "", 3, true},
+		{"page size 1", "", 1, false},
+		{"page size matching duplicates", "", 4, false},
+		{"filtered", "name:=odd", 2, false},
+		{"filtered descending", "name:=odd", 2, true},
+		{"single page", "", 50, false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := tstest.New(t)
+			docs := rankedCollection(t, c)
+
+			var want []rankedDoc
+			for _, doc := range docs {
+				if test.filter == "" || doc.Name == "odd" {
+					want = append(want, doc)
+				}
+			}
+
+			key := tsclient.Keyset{Field: "rank", Desc: test.desc}
+			data := tsclient.SearchData{Query: "*", FilterBy: test.filter, PerPage: test.perPage}
+
+			var (
+				got    []rankedDoc
+				cursor string
+				pages  int
+			)
+			for {
+				res, next, err := c.SearchAfter("docs", data, key, cursor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages++
+				if pages > len(want)+1 {
+					t.Fatal("cursor never ended")
+				}
+
+				for _, hit := range res.Hits {
+					var doc rankedDoc
+					err := hit.UnmarshalTo(&doc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, doc)
+				}
+
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			// every document is returned once, in rank order
+			if !sort.SliceIsSorted(got, func(i, j int) bool {
+				if test.desc {
+					return got[i].Rank > got[j].Rank
+				}
+				return got[i].Rank < got[j].Rank
+			}) {
+				t.Errorf("hits aren't sorted by rank: %v", got)
+			}
+
+			sort.Slice(got, func(i, j int) bool { return got[i].ID < got[j].ID })
+			sort.Slice(want, func(i, j int) bool { return want[i].ID < want[j].ID })
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v documents, want %v:\n%v", len(got), len(want), got)
+			}
+		})
+	}
+}
+
+func TestSearchAfterErrors(t *testing.T) {
+	c, _ := tstest.New(t)
+	rankedCollection(t, c)
+
+	key := tsclient.Keyset{Field: "rank"}
+	data := tsclient.SearchData{Query: "*", PerPage: 3}
+
+	// an invalid filter is an error, not an empty page
+	_, _, err := c.SearchAfter("docs", tsclient.SearchData{Query: "*", FilterBy: "not a filter"}, key, "")
+	if !errors.Is(err, tsclient.ErrBadRequest) {
+		t.Errorf("got %v for an invalid filter, want ErrBadRequest", err)
+	}
+
+	_, next, err := c.SearchAfter("docs", data, key, "")
+	if err != nil || next == "" {
+		t.Fatalf("got cursor %q, %v", next, err)
+	}
+	_, _, err = c.SearchAfter("docs", data, tsclient.Keyset{Field: "rank", Desc: true}, next)
+	if !errors.Is(err, tsclient.ErrInvalidCursor) {
+		t.Errorf("got %v for a cursor with a different order, want ErrInvalidCursor", err)
+	}
+
+	cursor := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for _, invalid := range []string{
+		"not base64!",
+		cursor(`not json`),
+		cursor(`{"f":"name","v":1}`),
+		cursor(`{"f":"rank","v":1e2}`),
+		cursor(`{"f":"rank","v":"1 || rank:>0"}`),
+		cursor(`{"f":"rank","v":1,"i":["a` + "`" + `b"]}`),
+	} {
+		_, _, err := c.SearchAfter("docs", data, key, invalid)
+		if !errors.Is(err, tsclient.ErrInvalidCursor) {
+			t.Errorf("got %v for cursor %q, want ErrInvalidCursor", err, invalid)
+		}
+	}
+
+	_, _, err = c.SearchAfter("docs", data, key, cursor(`{"f":"rank","v":2,"i":["8","9"]}`))
+	if err != nil {
+		t.Errorf("got %v for a valid cursor", err)
+	}
+}
@@ -0,0 +1,163 @@
+package tsclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a rate and concurrency limit for a class of requests.
+// The zero value is unlimited.
+type Limit struct {
+	// Maximum number of requests per second. Zero means no rate limit.
+	Rate float64
+	// Maximum number of requests that can be sent at once before being rate limited.
+	// Default: Rate rounded up, or 1 if Rate is below 1
+	Burst int
+
+	// Maximum number of requests in flight at once. Zero means no limit.
+	MaxInFlight int
+}
+
+// SetLimits sets limits for read requests (searches and retrieving documents or collections)
+// and write requests (creating, updating, importing and deleting).
+// Requests over the limit wait until they can be sent, or until the client's context is canceled.
+// Health checks are never limited.
+//
+// SetLimits is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) SetLimits(read, write Limit) {
+	c.limits = &limits{
+		read:  newLimiter(read),
+		write: newLimiter(write),
+	}
+}
+
+type limits struct {
+	read, write *limiter
+}
+
+type limiter struct {
+	bucket *tokenBucket
+	sem    chan struct{}
+}
+
+func newLimiter(l Limit) *limiter {
+	lim := &limiter{}
+
+	if l.Rate > 0 {
+		burst := float64(l.Burst)
+		if burst <= 0 {
+			burst = math.Max(1, math.Ceil(l.Rate))
+		}
+
+		lim.bucket = &tokenBucket{
+			rate:   l.Rate,
+			burst:  burst,
+			tokens: burst,
+			last:   time.Now(),
+		}
+	}
+
+	if l.MaxInFlight > 0 {
+		lim.sem = make(chan struct{}, l.MaxInFlight)
+	}
+
+	return lim
+}
+
+// acquire waits until the request can be sent, setting info.Queued to the time spent waiting.
+// release must be called once the request has finished.
+func (l *limits) acquire(info *RequestInfo) (release func(), err error) {
+	nop := func() {}
+	if l == nil || info.Endpoint == "/health" {
+		return nop, nil
+	}
+
+	lim := l.write
+	if isRead(info.Method, info.Endpoint) {
+		lim = l.read
+	}
+
+	ctx := info.Request.Context()
+	start := time.Now()
+	defer func() { info.Queued = time.Since(start) }()
+
+	if lim.bucket != nil {
+		err = lim.bucket.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if lim.sem == nil {
+		return nop, nil
+	}
+
+	select {
+	case lim.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-lim.sem }) }, nil
+}
+
+// isRead returns true if the request doesn't modify any data.
+func isRead(method, endpoint string) bool {
+	return method == "GET" || (method == "POST" && strings.HasPrefix(endpoint, "/multi_search"))
+}
+
+// tokenBucket is a token bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, waiting for one to become available if needed.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	// reserve a token, going into debt if there are none available
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// give the reserved token back
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releaseOnClose calls release when the body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
+}
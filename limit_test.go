@@ -0,0 +1,263 @@
+package tsclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+func TestLimitsConcurrency(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		inFlight, most map[string]int
+	)
+	inFlight, most = map[string]int{}, map[string]int{}
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight[r.Method]++
+		if inFlight[r.Method] > most[r.Method] {
+			most[r.Method] = inFlight[r.Method]
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		inFlight[r.Method]--
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewLazy(srv.URL, "key")
+	c.SetLimits(Limit{MaxInFlight: 2}, Limit{MaxInFlight: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		for _, method := range []string{"GET", "DELETE"} {
+			method := method
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Request(method, "/collections/terms/documents/1")
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+
+	// wait for the first requests, then give any requests over the limit time to arrive
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := inFlight["GET"] + inFlight["DELETE"]
+		mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	if most["GET"] != 2 {
+		t.Errorf("up to %v reads in flight, want 2", most["GET"])
+	}
+	if most["DELETE"] != 1 {
+		t.Errorf("up to %v writes in flight, want 1", most["DELETE"])
+	}
+}
+
+func TestLimitsStreamRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	c := NewLazy(srv.URL, "key")
+	c.SetLimits(Limit{MaxInFlight: 1}, Limit{})
+
+	body, err := c.ExportReader("terms", ExportData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the export holds the only slot until its body is closed, even once it has been read
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.WithContext(ctx).Request("GET", "/collections")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v while the export was open, want context.DeadlineExceeded", err)
+	}
+
+	// closing more than once only releases the slot once
+	_ = body.Close()
+	_ = body.Close()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.WithContext(ctx).Request("GET", "/collections")
+	if err != nil {
+		t.Errorf("got %v after closing the export", err)
+	}
+	if len(c.limits.read.sem) != 0 {
+		t.Errorf("%v slots in use after all requests finished, want 0", len(c.limits.read.sem))
+	}
+}
+
+func TestLimitsAcquireCanceled(t *testing.T) {
+	l := &limits{
+		read:  newLimiter(Limit{MaxInFlight: 1}),
+		write: newLimiter(Limit{Rate: 1, Burst: 1}),
+	}
+
+	info := func(ctx context.Context, method, endpoint string) *RequestInfo {
+		req, err := http.NewRequestWithContext(ctx, method, "http://localhost"+endpoint, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &RequestInfo{Method: method, Endpoint: endpoint, Request: req}
+	}
+
+	// waiting for a slot
+	release, err := l.acquire(info(context.Background(), "GET", "/collections"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	queued := info(ctx, "GET", "/collections")
+	_, err = l.acquire(queued)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v waiting for a slot, want context.DeadlineExceeded", err)
+	}
+	if queued.Queued < 20*time.Millisecond {
+		t.Errorf("queued for %v, want at least 20ms", queued.Queued)
+	}
+
+	// health checks aren't limited
+	done, err := l.acquire(info(context.Background(), "GET", "/health"))
+	if err != nil {
+		t.Errorf("got %v for a health check", err)
+	}
+	done()
+
+	// waiting for a token
+	_, err = l.acquire(info(context.Background(), "POST", "/collections"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = l.acquire(info(ctx, "POST", "/collections"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v waiting for a token, want context.Canceled", err)
+	}
+
+	// the canceled request's token is given back
+	b := l.write.bucket
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("bucket has %v tokens after a canceled wait, want about 0", tokens)
+	}
+}
+
+func TestIsRead(t *testing.T) {
+	tests := []struct {
+		method, endpoint string
+		want             bool
+	}{
+		{"GET", "/collections/terms/documents/search", true},
+		{"GET", "/collections", true},
+		{"POST", "/multi_search", true},
+		{"POST", "/multi_search?q=*", true},
+		{"POST", "/collections/terms/documents", false},
+		{"POST", "/collections/terms/documents/import", false},
+		{"PATCH", "/collections/terms/documents/1", false},
+		{"PUT", "/aliases/terms", false},
+		{"DELETE", "/collections/terms", false},
+	}
+
+	for _, test := range tests {
+		if got := isRead(test.method, test.endpoint); got != test.want {
+			t.Errorf("isRead(%q, %q) = %v, want %v", test.method, test.endpoint, got, test.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	tests := []struct {
+		limit     Limit
+		wantBurst float64
+	}{
+		{Limit{Rate: 10, Burst: 2}, 2},
+		{Limit{Rate: 2.5}, 3},
+		{Limit{Rate: 0.5}, 1},
+	}
+	for _, test := range tests {
+		if b := newLimiter(test.limit).bucket; b.burst != test.wantBurst || b.tokens != test.wantBurst {
+			t.Errorf("%+v: got burst %v with %v tokens, want %v", test.limit, b.burst, b.tokens, test.wantBurst)
+		}
+	}
+	if newLimiter(Limit{}).bucket != nil {
+		t.Error("got a token bucket without a rate")
+	}
+
+	b := newLimiter(Limit{Rate: 10, Burst: 2}).bucket
+	ctx := context.Background()
+
+	// the burst is available immediately, then requests are spaced out by the rate
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		err := b.wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("burst took %v, want no wait", d)
+	}
+
+	start = time.Now()
+	err := b.wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Errorf("waited %v for a token, want about 100ms", d)
+	}
+
+	// tokens refill up to the burst
+	b.mu.Lock()
+	b.last = time.Now().Add(-time.Hour)
+	b.mu.Unlock()
+	err = b.wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < 0.99 || tokens > 1.01 {
+		t.Errorf("got %v tokens after refilling, want 1", tokens)
+	}
+}
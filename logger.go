@@ -0,0 +1,96 @@
+package tsclient
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Logger is a leveled, structured logger.
+// args are alternating keys and values, in the same format as log/slog, so a *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NopLogger is a Logger that discards all messages. It is the default logger.
+type NopLogger struct{}
+
+var _ Logger = NopLogger{}
+
+// Debug implements Logger.
+func (NopLogger) Debug(string, ...interface{}) {}
+
+// Info implements Logger.
+func (NopLogger) Info(string, ...interface{}) {}
+
+// Warn implements Logger.
+func (NopLogger) Warn(string, ...interface{}) {}
+
+// Error implements Logger.
+func (NopLogger) Error(string, ...interface{}) {}
+
+// Redacted replaces API keys in log messages.
+const Redacted = "[REDACTED]"
+
+// redactingLogger removes the client's API key and any scoped API keys from log arguments before passing them on.
+type redactingLogger struct {
+	l      Logger
+	apiKey string
+}
+
+func (c *Client) log() Logger {
+	if _, ok := c.Logger.(NopLogger); ok || c.Logger == nil {
+		return NopLogger{}
+	}
+	return redactingLogger{c.Logger, c.apiKey}
+}
+
+func (r redactingLogger) Debug(msg string, args ...interface{}) { r.l.Debug(msg, r.redact(args)...) }
+func (r redactingLogger) Info(msg string, args ...interface{})  { r.l.Info(msg, r.redact(args)...) }
+func (r redactingLogger) Warn(msg string, args ...interface{})  { r.l.Warn(msg, r.redact(args)...) }
+func (r redactingLogger) Error(msg string, args ...interface{}) { r.l.Error(msg, r.redact(args)...) }
+
+// apiKeyParam matches API keys passed as query parameters, as in URLs included in error messages.
+var apiKeyParam = regexp.MustCompile(`(?i)(x-typesense-api-key=)[^&\s"]+`)
+
+func (r redactingLogger) redact(args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+
+	for i, arg := range args {
+		if i%2 == 1 && isKeyName(args[i-1]) {
+			out[i] = Redacted
+			continue
+		}
+
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case error:
+			s = v.Error()
+		default:
+			out[i] = arg
+			continue
+		}
+
+		if r.apiKey != "" {
+			s = strings.ReplaceAll(s, r.apiKey, Redacted)
+		}
+		out[i] = apiKeyParam.ReplaceAllString(s, "${1}"+Redacted)
+	}
+
+	return out
+}
+
+// isKeyName returns true if the log key k names an API key.
+func isKeyName(k interface{}) bool {
+	s, ok := k.(string)
+	if !ok {
+		return false
+	}
+
+	s = strings.ToLower(s)
+	return strings.Contains(s, "api_key") || strings.Contains(s, "api-key") || strings.Contains(s, "apikey")
+}
@@ -0,0 +1,294 @@
+package tsclient
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+)
+
+// DefaultLatencyBuckets are the default latency histogram buckets used by Metrics, in seconds.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects metrics for requests made by a client,
+// and serves them in the Prometheus text exposition format.
+//
+// Requests are grouped by endpoint family: search, import, export, documents, collections, health, and other.
+//
+//	m := tsclient.NewMetrics()
+//	client.Use(m.Middleware())
+//	http.Handle("/metrics", m)
+type Metrics struct {
+	mu sync.Mutex
+
+	buckets []float64
+
+	requests map[[2]string]uint64
+	errors   map[[2]string]uint64
+	families map[string]*familyMetrics
+}
+
+// familyMetrics are the metrics recorded for each endpoint family.
+type familyMetrics struct {
+	bytesSent     uint64
+	bytesReceived uint64
+	queued        float64
+	latency       histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics returns a new Metrics using DefaultLatencyBuckets.
+func NewMetrics() *Metrics {
+	return NewMetricsWithBuckets(DefaultLatencyBuckets)
+}
+
+// NewMetricsWithBuckets returns a new Metrics using the given latency histogram buckets, in seconds.
+func NewMetricsWithBuckets(buckets []float64) *Metrics {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+
+	return &Metrics{
+		buckets:  b,
+		requests: map[[2]string]uint64{},
+		errors:   map[[2]string]uint64{},
+		families: map[string]*familyMetrics{},
+	}
+}
+
+// Middleware returns a Middleware recording metrics for every request.
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(info *RequestInfo) error {
+			err := next(info)
+
+			family := endpointFamily(info.Endpoint)
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			m.requests[[2]string{family, info.Method}]++
+			if err != nil {
+				m.errors[[2]string{family, errorReason(info, err)}]++
+			}
+
+			fm, ok := m.families[family]
+			if !ok {
+				fm = &familyMetrics{latency: histogram{counts: make([]uint64, len(m.buckets))}}
+				m.families[family] = fm
+			}
+
+			// counted for every attempt, including retries and hedged requests
+			fm.bytesSent += uint64(info.sent)
+			fm.bytesReceived += uint64(len(info.Body))
+			if info.body != nil {
+				info.body = &countingReader{ReadCloser: info.body, m: m, family: fm}
+			}
+
+			fm.queued += info.Queued.Seconds()
+
+			h := &fm.latency
+			secs := info.Latency.Seconds()
+			for i, b := range m.buckets {
+				if secs <= b {
+					h.counts[i]++
+				}
+			}
+			h.count++
+			h.sum += secs
+
+			return err
+		}
+	}
+}
+
+// countingReader counts bytes read from streamed response bodies.
+type countingReader struct {
+	io.ReadCloser
+	m      *Metrics
+	family *familyMetrics
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+
+	r.m.mu.Lock()
+	r.family.bytesReceived += uint64(n)
+	r.m.mu.Unlock()
+
+	return n, err
+}
+
+// endpointFamily returns the group of endpoints endpoint belongs to.
+func endpointFamily(endpoint string) string {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+
+	switch {
+	case parts[0] == "health":
+		return "health"
+	case parts[0] == "multi_search":
+		return "search"
+	case parts[0] != "collections":
+		return "other"
+	case len(parts) <= 2:
+		return "collections"
+	case parts[2] != "documents":
+		return "other"
+	case len(parts) == 4 && parts[3] == "search":
+		return "search"
+	case len(parts) == 4 && parts[3] == "import":
+		return "import"
+	case len(parts) == 4 && parts[3] == "export":
+		return "export"
+	default:
+		return "documents"
+	}
+}
+
+// errorReason returns the status code of a failed request, or a short description of the error if there was no response.
+func errorReason(info *RequestInfo, err error) string {
+	if info.StatusCode != 0 {
+		return strconv.Itoa(info.StatusCode)
+	}
+
+	var sentinel errors.Sentinel
+	switch {
+	case errors.As(err, &sentinel):
+		return string(sentinel)
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "network"
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	header(cw, "tsclient_requests_total", "counter", "Total number of requests made to Typesense.")
+	for _, k := range sortedPairs(m.requests) {
+		metric(cw, "tsclient_requests_total", labels("family", k[0], "method", k[1]), float64(m.requests[k]))
+	}
+
+	header(cw, "tsclient_request_errors_total", "counter", "Total number of failed requests, by status code or error.")
+	for _, k := range sortedPairs(m.errors) {
+		metric(cw, "tsclient_request_errors_total", labels("family", k[0], "reason", k[1]), float64(m.errors[k]))
+	}
+
+	families := m.sortedFamilies()
+
+	header(cw, "tsclient_sent_bytes_total", "counter", "Total number of request body bytes sent, including retries.")
+	for _, family := range families {
+		metric(cw, "tsclient_sent_bytes_total", labels("family", family), float64(m.families[family].bytesSent))
+	}
+
+	header(cw, "tsclient_received_bytes_total", "counter", "Total number of response body bytes received.")
+	for _, family := range families {
+		metric(cw, "tsclient_received_bytes_total", labels("family", family), float64(m.families[family].bytesReceived))
+	}
+
+	header(cw, "tsclient_queue_wait_seconds_total", "counter", "Total time requests spent waiting for rate and concurrency limits.")
+	for _, family := range families {
+		metric(cw, "tsclient_queue_wait_seconds_total", labels("family", family), m.families[family].queued)
+	}
+
+	header(cw, "tsclient_request_duration_seconds", "histogram", "Request latency in seconds.")
+	for _, family := range families {
+		h := m.families[family].latency
+		for i, b := range m.buckets {
+			metric(cw, "tsclient_request_duration_seconds_bucket",
+				labels("family", family, "le", strconv.FormatFloat(b, 'g', -1, 64)), float64(h.counts[i]))
+		}
+		metric(cw, "tsclient_request_duration_seconds_bucket", labels("family", family, "le", "+Inf"), float64(h.count))
+		metric(cw, "tsclient_request_duration_seconds_sum", labels("family", family), h.sum)
+		metric(cw, "tsclient_request_duration_seconds_count", labels("family", family), float64(h.count))
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) WriteString(s string) {
+	if w.err != nil {
+		return
+	}
+
+	n, err := io.WriteString(w.w, s)
+	w.n += int64(n)
+	w.err = err
+}
+
+func header(w *countingWriter, name, typ, help string) {
+	w.WriteString("# HELP " + name + " " + help + "\n# TYPE " + name + " " + typ + "\n")
+}
+
+func metric(w *countingWriter, name, labels string, value float64) {
+	w.WriteString(name + labels + " " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats alternating label names and values.
+func labels(kv ...string) string {
+	s := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		s = append(s, kv[i]+`="`+labelEscaper.Replace(kv[i+1])+`"`)
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+// sortedFamilies returns the endpoint families with recorded requests, sorted by name.
+func (m *Metrics) sortedFamilies() []string {
+	families := make([]string, 0, len(m.families))
+	for family := range m.families {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	return families
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
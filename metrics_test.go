@@ -0,0 +1,138 @@
+package tsclient_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+func TestMetrics(t *testing.T) {
+	const (
+		aliasBody  = `{"collection_name":"terms"}`
+		aliasResp  = `{"name":"terms","collection_name":"terms"}`
+		searchResp = `{"found":0,"hits":[]}`
+		notFound   = `{"message":"Not Found"}`
+		exported   = "{\"id\":\"1\"}\n{\"id\":\"2\"}\n"
+	)
+
+	var aliasRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aliases/terms":
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != aliasBody {
+				t.Errorf("got alias body %q, want %q", b, aliasBody)
+			}
+			// the first attempt fails, and is retried
+			if atomic.AddInt32(&aliasRequests, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = io.WriteString(w, aliasResp)
+		case "/collections/terms/documents/search":
+			_, _ = io.WriteString(w, searchResp)
+		case "/collections/terms/documents/export":
+			_, _ = io.WriteString(w, exported)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, notFound)
+		}
+	}))
+	defer srv.Close()
+
+	m := tsclient.NewMetricsWithBuckets([]float64{60, 0})
+	c := tsclient.NewLazy(srv.URL, "key")
+	c.MaxRetries = 1
+	c.RetryBackoff = time.Millisecond
+	c.Use(m.Middleware())
+
+	_, err := c.Request("PUT", "/aliases/terms", tsclient.WithBody(strings.NewReader(aliasBody)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Request("GET", "/collections/terms/documents/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = c.Request("GET", "/collections/missing")
+
+	export, err := c.ExportReader("terms", tsclient.ExportData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, export)
+	_ = export.Close()
+
+	n := func(s string) string { return strconv.Itoa(len(s)) }
+	want := `# HELP tsclient_requests_total Total number of requests made to Typesense.
+# TYPE tsclient_requests_total counter
+tsclient_requests_total{family="collections",method="GET"} 1
+tsclient_requests_total{family="export",method="GET"} 1
+tsclient_requests_total{family="other",method="PUT"} 1
+tsclient_requests_total{family="search",method="GET"} 1
+# HELP tsclient_request_errors_total Total number of failed requests, by status code or error.
+# TYPE tsclient_request_errors_total counter
+tsclient_request_errors_total{family="collections",reason="404"} 1
+# HELP tsclient_sent_bytes_total Total number of request body bytes sent, including retries.
+# TYPE tsclient_sent_bytes_total counter
+tsclient_sent_bytes_total{family="collections"} 0
+tsclient_sent_bytes_total{family="export"} 0
+tsclient_sent_bytes_total{family="other"} ` + strconv.Itoa(2*len(aliasBody)) + `
+tsclient_sent_bytes_total{family="search"} 0
+# HELP tsclient_received_bytes_total Total number of response body bytes received.
+# TYPE tsclient_received_bytes_total counter
+tsclient_received_bytes_total{family="collections"} ` + n(notFound) + `
+tsclient_received_bytes_total{family="export"} ` + n(exported) + `
+tsclient_received_bytes_total{family="other"} ` + n(aliasResp) + `
+tsclient_received_bytes_total{family="search"} ` + n(searchResp) + `
+# HELP tsclient_queue_wait_seconds_total Total time requests spent waiting for rate and concurrency limits.
+# TYPE tsclient_queue_wait_seconds_total counter
+tsclient_queue_wait_seconds_total{family="collections"} 0
+tsclient_queue_wait_seconds_total{family="export"} 0
+tsclient_queue_wait_seconds_total{family="other"} 0
+tsclient_queue_wait_seconds_total{family="search"} 0
+# HELP tsclient_request_duration_seconds Request latency in seconds.
+# TYPE tsclient_request_duration_seconds histogram
+`
+	for _, family := range []string{"collections", "export", "other", "search"} {
+		want += `tsclient_request_duration_seconds_bucket{family="` + family + `",le="0"} 0
+tsclient_request_duration_seconds_bucket{family="` + family + `",le="60"} 1
+tsclient_request_duration_seconds_bucket{family="` + family + `",le="+Inf"} 1
+tsclient_request_duration_seconds_sum{family="` + family + `"} SUM
+tsclient_request_duration_seconds_count{family="` + family + `"} 1
+`
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got content type %q", ct)
+	}
+
+	// latencies vary, so only check that the sums are positive numbers
+	sums := regexp.MustCompile(`(?m)(_sum\{.*\}) [0-9.e-]+$`)
+	got := rec.Body.String()
+	if strings.Contains(got, "_sum{family=\"other\"} 0\n") {
+		t.Error("got a latency sum of 0")
+	}
+	got = sums.ReplaceAllString(got, "$1 SUM")
+	if got != want {
+		t.Errorf("got metrics:\n%v\nwant:\n%v", got, want)
+	}
+
+	// WriteTo returns the number of bytes written
+	var b strings.Builder
+	written, err := m.WriteTo(&b)
+	if err != nil || written != int64(b.Len()) {
+		t.Errorf("WriteTo returned %v, %v for %v bytes", written, err, b.Len())
+	}
+}
@@ -0,0 +1,76 @@
+package tsclient
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// RequestInfo holds information about a single request, and is passed to middleware.
+type RequestInfo struct {
+	Method string
+	// The endpoint the request is made to, without the base URL or query parameters.
+	// Collection names and document IDs in the path are escaped.
+	Endpoint string
+	// The HTTP request. Middleware can modify it (for example, to add headers) before calling the next handler.
+	Request *http.Request
+
+	// The following fields are set once the request has completed.
+
+	// The HTTP status code of the response. Zero if no response was received.
+	StatusCode int
+	// The response body. Nil for streamed responses, such as exports.
+	Body []byte
+	// Time taken to send the request and read the response, including any retries.
+	Latency time.Duration
+	// Time spent waiting for rate and concurrency limits before the request was sent.
+	Queued time.Duration
+	// Number of times the request was retried.
+	Retries int
+	// True if a hedged request was sent to a second node. See Client.EnableHedging.
+	Hedged bool
+	// The error returned by the request, if any.
+	Err error
+
+	stream bool
+	// request body bytes sent, counting every attempt
+	sent int64
+	// unread response body for streamed responses
+	body io.ReadCloser
+}
+
+// Handler sends a request, filling in the response fields of info.
+type Handler func(info *RequestInfo) error
+
+// Middleware wraps a Handler, running code before the request is sent and after the response is received.
+// Middleware can also return early without calling next, for example to reject a request.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to the client. Middleware is called in the order it was added,
+// with the first middleware being the outermost.
+// Use is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// Hooks returns a Middleware calling before before each request is sent, and after after it has completed.
+// Either function may be nil.
+func Hooks(before, after func(info *RequestInfo)) Middleware {
+	return func(next Handler) Handler {
+		return func(info *RequestInfo) error {
+			if before != nil {
+				before(info)
+			}
+
+			err := next(info)
+			if err != nil && info.Err == nil {
+				info.Err = err
+			}
+
+			if after != nil {
+				after(info)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package tsclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// ErrCircuitOpen is returned if a request can't be sent because the circuit breakers of all nodes are open.
+const ErrCircuitOpen = errors.Sentinel("circuit breaker open")
+
+// nodePool is the list of nodes a client sends requests to.
+type nodePool struct {
+	nodes []*node
+	next  uint32
+}
+
+type node struct {
+	url     string
+	breaker *breaker
+}
+
+func newNodePool(urls []string) *nodePool {
+	p := &nodePool{}
+	for _, u := range urls {
+		p.nodes = append(p.nodes, &node{url: strings.TrimSuffix(u, "/")})
+	}
+	return p
+}
+
+// pick returns the next node that is allowed to receive requests, in round-robin order.
+// Nodes in tried are skipped unless there are no others available.
+// probe is true if the request is the probe of a half-open breaker, and must be passed to the breaker's finish or release.
+func (p *nodePool) pick(tried map[*node]bool) (nd *node, probe bool, err error) {
+	n := len(p.nodes)
+	if n == 1 {
+		probe, ok := p.nodes[0].breaker.allow()
+		if !ok {
+			return nil, false, ErrCircuitOpen
+		}
+		return p.nodes[0], probe, nil
+	}
+
+	start := int(atomic.AddUint32(&p.next, 1) - 1)
+
+	for _, skipTried := range []bool{true, false} {
+		for i := 0; i < n; i++ {
+			nd := p.nodes[(start+i)%n]
+			if skipTried && tried[nd] {
+				continue
+			}
+
+			if probe, ok := nd.breaker.allow(); ok {
+				return nd, probe, nil
+			}
+		}
+	}
+
+	return nil, false, ErrCircuitOpen
+}
+
+// requestURL returns the URL for a request to endpoint on the node, keeping the query parameters of u.
+func (nd *node) requestURL(endpoint string, u *url.URL) (*url.URL, error) {
+	nu, err := url.Parse(nd.url + endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	nu.RawQuery = u.RawQuery
+	return nu, nil
+}
+
+// BreakerConfig is the configuration for the circuit breakers of a client's nodes.
+// All fields are optional.
+type BreakerConfig struct {
+	// Number of consecutive failures after which the breaker opens, and requests to the node fail fast.
+	// Default: 5
+	FailureThreshold int
+	// Time after which an open breaker lets a single probe request through.
+	// If the probe succeeds, the breaker closes, otherwise it stays open for another interval.
+	// Default: 10 seconds
+	ProbeInterval time.Duration
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Circuit breaker states.
+const (
+	// Requests are sent normally.
+	BreakerClosed BreakerState = iota
+	// Requests fail fast with ErrCircuitOpen.
+	BreakerOpen
+	// A single probe request is allowed through.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// SetBreaker enables a circuit breaker for each node.
+// Requests fail over to other nodes while a node's breaker is open; if all breakers are open, they fail with ErrCircuitOpen.
+// Network errors and 5xx responses count as failures.
+//
+// SetBreaker is not safe for concurrent use with requests, and should be called when setting up the client.
+func (c *Client) SetBreaker(cfg BreakerConfig) {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = 10 * time.Second
+	}
+
+	for _, nd := range c.nodes.nodes {
+		nd.breaker = &breaker{
+			cfg:  cfg,
+			node: nd.url,
+			log:  c.log,
+		}
+	}
+}
+
+// BreakerStates returns the state of each node's circuit breaker,
+// in the same order as the URLs the client was created with.
+// Nodes without a breaker are always closed.
+func (c *Client) BreakerStates() []BreakerState {
+	states := make([]BreakerState, len(c.nodes.nodes))
+	for i, nd := range c.nodes.nodes {
+		states[i] = nd.breaker.state()
+	}
+	return states
+}
+
+type breaker struct {
+	cfg  BreakerConfig
+	node string
+	log  func() Logger
+
+	mu       sync.Mutex
+	st       BreakerState
+	failures int
+	openedAt time.Time
+	// true while the probe of a half-open breaker is in flight
+	probing bool
+}
+
+// allow returns true if a request may be sent to the node.
+// probe is true if the request is the single probe let through by a half-open breaker.
+// A nil breaker always allows requests.
+func (b *breaker) allow() (probe, ok bool) {
+	if b == nil {
+		return false, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.st {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.ProbeInterval {
+			return false, false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true, true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	default:
+		return false, true
+	}
+}
+
+// record records the result of a request allowed by allow.
+// Only the probe's result changes the state of a half-open breaker:
+// other requests were allowed before the breaker opened, and say nothing about whether the node has recovered.
+func (b *breaker) record(probe, success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+		if success {
+			b.failures = 0
+			b.setState(BreakerClosed)
+		} else {
+			b.openedAt = time.Now()
+			b.setState(BreakerOpen)
+		}
+		return
+	}
+
+	if b.st != BreakerClosed {
+		return
+	}
+
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState changes the breaker's state. b.mu must be held.
+func (b *breaker) setState(st BreakerState) {
+	b.log().Warn("circuit breaker state changed", "node", b.node, "from", b.st.String(), "to", st.String(), "failures", b.failures)
+	b.st = st
+}
+
+func (b *breaker) state() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.st
+}
+
+// finish records the result of a request allowed by allow.
+// Network errors and 5xx responses count as failures.
+// Requests canceled by the caller say nothing about the node's health, so they aren't counted.
+func (b *breaker) finish(ctx context.Context, info *RequestInfo, probe bool) {
+	if b == nil {
+		return
+	}
+
+	if info.StatusCode == 0 && ctx.Err() != nil {
+		b.release(probe)
+		return
+	}
+
+	if info.StatusCode != 0 {
+		b.record(probe, info.StatusCode < http.StatusInternalServerError)
+		return
+	}
+	b.record(probe, info.Err == nil)
+}
+
+// release releases a request allowed by allow without recording a result.
+// If it was the probe, another probe is let through.
+func (b *breaker) release(probe bool) {
+	if b == nil || !probe {
+		return
+	}
+
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
@@ -0,0 +1,166 @@
+package tsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(threshold int, interval time.Duration) *breaker {
+	return &breaker{
+		cfg:  BreakerConfig{FailureThreshold: threshold, ProbeInterval: interval},
+		node: "test",
+		log:  func() Logger { return NopLogger{} },
+	}
+}
+
+func expectState(t *testing.T, b *breaker, want BreakerState) {
+	t.Helper()
+	if got := b.state(); got != want {
+		t.Fatalf("breaker is %v, want %v", got, want)
+	}
+}
+
+func expectAllow(t *testing.T, b *breaker, wantProbe, wantOK bool) {
+	t.Helper()
+	probe, ok := b.allow()
+	if probe != wantProbe || ok != wantOK {
+		t.Fatalf("allow() = %v, %v, want %v, %v", probe, ok, wantProbe, wantOK)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	b := newTestBreaker(2, interval)
+
+	expectAllow(t, b, false, true)
+	b.record(false, false)
+	expectState(t, b, BreakerClosed)
+
+	// a success resets the consecutive failure count
+	b.record(false, true)
+	b.record(false, false)
+	expectState(t, b, BreakerClosed)
+	b.record(false, false)
+	expectState(t, b, BreakerOpen)
+	expectAllow(t, b, false, false)
+
+	// a single probe is let through after the interval
+	time.Sleep(interval)
+	expectAllow(t, b, true, true)
+	expectState(t, b, BreakerHalfOpen)
+	expectAllow(t, b, false, false)
+
+	// a failed probe opens the breaker again
+	b.record(true, false)
+	expectState(t, b, BreakerOpen)
+	expectAllow(t, b, false, false)
+
+	// a probe released without a result lets another probe through
+	time.Sleep(interval)
+	expectAllow(t, b, true, true)
+	b.release(true)
+	expectState(t, b, BreakerHalfOpen)
+	expectAllow(t, b, true, true)
+
+	// a successful probe closes it
+	b.record(true, true)
+	expectState(t, b, BreakerClosed)
+	expectAllow(t, b, false, true)
+}
+
+func TestBreakerIgnoresLateResults(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	b := newTestBreaker(1, interval)
+
+	// started while the breaker was closed, finishes after it is half-open
+	expectAllow(t, b, false, true)
+
+	b.record(false, false)
+	expectState(t, b, BreakerOpen)
+
+	time.Sleep(interval)
+	expectAllow(t, b, true, true)
+
+	b.record(false, true)
+	expectState(t, b, BreakerHalfOpen)
+	b.release(false)
+	expectAllow(t, b, false, false)
+
+	b.record(false, false)
+	expectState(t, b, BreakerHalfOpen)
+
+	// only the probe's result counts
+	b.record(true, false)
+	expectState(t, b, BreakerOpen)
+}
+
+func TestBreakerFailover(t *testing.T) {
+	var healthy int32
+	var badRequests, goodRequests int32
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badRequests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer bad.Close()
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodRequests, 1)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer good.Close()
+
+	// states are returned in the order of the URLs, however they are written
+	c, err := NewNodesLazy([]string{bad.URL + "/", good.URL}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.BreakerStates()); n != 2 {
+		t.Fatalf("got %v breaker states, want 2", n)
+	}
+	const interval = 50 * time.Millisecond
+	c.SetBreaker(BreakerConfig{FailureThreshold: 3, ProbeInterval: interval})
+
+	health := func(n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.Health()
+			}()
+		}
+		wg.Wait()
+	}
+
+	health(20)
+	if got := c.BreakerStates()[0]; got != BreakerOpen {
+		t.Fatalf("failing node's breaker is %v, want open", got)
+	}
+
+	// while the breaker is open, every request goes to the other node
+	before := atomic.LoadInt32(&badRequests)
+	health(20)
+	if got := atomic.LoadInt32(&badRequests); got != before {
+		t.Errorf("failing node got %v requests while its breaker was open", got-before)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(interval)
+
+	health(20)
+	if got := c.BreakerStates()[0]; got != BreakerClosed {
+		t.Errorf("recovered node's breaker is %v, want closed", got)
+	}
+	if got := c.BreakerStates()[1]; got != BreakerClosed {
+		t.Errorf("healthy node's breaker is %v, want closed", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 // RequestOption is an optional request option.
@@ -40,6 +41,30 @@ func WithBody(r io.Reader) func(*http.Request) error {
 
 		req.Body = rc
 
+		// allow the body to be sent again if the request is retried
+		switch v := r.(type) {
+		case *bytes.Buffer:
+			b := v.Bytes()
+			req.ContentLength = int64(len(b))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(b)), nil
+			}
+		case *bytes.Reader:
+			req.ContentLength = int64(v.Len())
+			snapshot := *v
+			req.GetBody = func() (io.ReadCloser, error) {
+				r := snapshot
+				return io.NopCloser(&r), nil
+			}
+		case *strings.Reader:
+			req.ContentLength = int64(v.Len())
+			snapshot := *v
+			req.GetBody = func() (io.ReadCloser, error) {
+				r := snapshot
+				return io.NopCloser(&r), nil
+			}
+		}
+
 		return nil
 	}
 }
@@ -58,6 +83,10 @@ func WithJSONBody(v interface{}) func(*http.Request) error {
 
 		req.Header.Add("Content-Type", "application/json")
 		req.Body = io.NopCloser(bytes.NewReader(b))
+		req.ContentLength = int64(len(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
 		return nil
 	}
 }
@@ -0,0 +1,55 @@
+package tsclient
+
+import (
+	"net/url"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// Errors returned before a request is sent, if a path segment is invalid.
+const (
+	ErrEmptyCollection = errors.Sentinel("collection name is empty")
+	ErrEmptyID         = errors.Sentinel("document ID is empty")
+)
+
+// Path builds an endpoint from path segments, escaping each one.
+// Use it to build endpoints for (*Client).Request from collection names, document IDs and other user input:
+//
+//	client.Request("GET", tsclient.Path("collections", name, "synonyms", id))
+func Path(segments ...string) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		b.WriteByte('/')
+
+		// dot segments are escaped so they aren't resolved as relative paths along the way
+		switch seg {
+		case ".":
+			b.WriteString("%2E")
+		case "..":
+			b.WriteString("%2E%2E")
+		default:
+			b.WriteString(url.PathEscape(seg))
+		}
+	}
+	return b.String()
+}
+
+// collectionPath returns the endpoint for the collection, followed by segments.
+func collectionPath(collection string, segments ...string) (string, error) {
+	if collection == "" {
+		return "", ErrEmptyCollection
+	}
+	return Path(append([]string{"collections", collection}, segments...)...), nil
+}
+
+// documentPath returns the endpoint for a single document in the collection.
+func documentPath(collection, id string) (string, error) {
+	if collection == "" {
+		return "", ErrEmptyCollection
+	}
+	if id == "" {
+		return "", ErrEmptyID
+	}
+	return Path("collections", collection, "documents", id), nil
+}
@@ -0,0 +1,26 @@
+package tsclient_test
+
+import (
+	"testing"
+
+	"github.com/termora/tsclient"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		segments []string
+		want     string
+	}{
+		{[]string{"collections"}, "/collections"},
+		{[]string{"collections", "terms", "synonyms", "a b"}, "/collections/terms/synonyms/a%20b"},
+		{[]string{"collections", "a/b", "documents", "?#%"}, "/collections/a%2Fb/documents/%3F%23%25"},
+		{[]string{"aliases", "."}, "/aliases/%2E"},
+		{[]string{"aliases", ".."}, "/aliases/%2E%2E"},
+	}
+
+	for _, test := range tests {
+		if got := tsclient.Path(test.segments...); got != test.want {
+			t.Errorf("Path(%q) = %q, want %q", test.segments, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package tsclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// Errors describing the last health check, returned by WaitReady.
+const (
+	// The server responded, but is still starting up or loading data.
+	ErrNotReady = errors.Sentinel("typesense is not ready")
+	// The server couldn't be reached.
+	ErrUnreachable = errors.Sentinel("typesense is unreachable")
+)
+
+const (
+	readyInitialBackoff = 250 * time.Millisecond
+	readyMaxBackoff     = 5 * time.Second
+	// timeout for a single health check, so a hanging server is retried
+	readyCheckTimeout = 5 * time.Second
+)
+
+// NewLazy creates a new Client without pinging the server.
+// Use WaitReady to wait until the server is up.
+func NewLazy(url, apiKey string) *Client {
+	c, _ := newClient([]string{url}, apiKey)
+	return c
+}
+
+// NewNodesLazy creates a new Client sending requests to multiple nodes, without pinging the cluster.
+// Use WaitReady to wait until the cluster is up.
+func NewNodesLazy(urls []string, apiKey string) (*Client, error) {
+	return newClient(urls, apiKey)
+}
+
+// WaitReady polls the health endpoint with exponential backoff until the server reports that it is ready,
+// or until ctx is done. Progress is logged to the client's Logger at info level.
+//
+// Network errors and timeouts are reported as the server being unreachable,
+// and 503 Service Unavailable or "ok": false responses as the server not being ready yet (for example, while loading data).
+// Any other error response is returned immediately.
+//
+// If ctx is done first, the returned error matches ctx.Err() and either ErrNotReady or ErrUnreachable with errors.Is,
+// depending on the result of the last health check.
+func (c *Client) WaitReady(ctx context.Context) error {
+	backoff := readyInitialBackoff
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		state, err := c.checkReady(ctx)
+		if state == nil {
+			if attempt > 1 {
+				c.log().Info("typesense is ready", "attempts", attempt, "waited", time.Since(start))
+			}
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return &waitError{state: state, last: err, ctxErr: ctx.Err()}
+		}
+
+		c.log().Info("waiting for typesense", "state", state.Error(), "attempt", attempt, "error", err, "retry_in", backoff)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return &waitError{state: state, last: err, ctxErr: ctx.Err()}
+		}
+
+		backoff *= 2
+		if backoff > readyMaxBackoff {
+			backoff = readyMaxBackoff
+		}
+	}
+}
+
+// checkReady performs a single health check.
+// state is ErrNotReady or ErrUnreachable if the check should be retried, and nil otherwise.
+func (c *Client) checkReady(ctx context.Context) (state, err error) {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	info, err := c.WithContext(ctx).do("GET", "/health", false)
+	if info == nil {
+		return nil, err
+	}
+
+	switch {
+	case info.StatusCode == 0:
+		return ErrUnreachable, err
+	case info.StatusCode == http.StatusServiceUnavailable:
+		return ErrNotReady, err
+	case err != nil:
+		return nil, err
+	}
+
+	s := struct {
+		OK bool `json:"ok"`
+	}{}
+
+	err = json.Unmarshal(info.Body, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.OK {
+		return ErrNotReady, errors.New(`health check returned "ok": false`)
+	}
+	return nil, nil
+}
+
+// waitError is returned by WaitReady if the context is done before the server is ready.
+type waitError struct {
+	// ErrNotReady or ErrUnreachable
+	state error
+	// the error returned by the last health check
+	last   error
+	ctxErr error
+}
+
+func (e *waitError) Error() string {
+	msg := "waiting for typesense: " + e.ctxErr.Error() + ": " + e.state.Error()
+	if e.last != nil {
+		msg += ": " + e.last.Error()
+	}
+	return msg
+}
+
+func (e *waitError) Is(target error) bool {
+	return target == e.state || target == e.ctxErr
+}
+
+func (e *waitError) Unwrap() error {
+	return e.last
+}
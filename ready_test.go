@@ -0,0 +1,197 @@
+package tsclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+// startingServer returns a fake Typesense server whose first n health checks get the given status code and body.
+// After that, it serves requests with a tstest server.
+func startingServer(t *testing.T, n int32, status int, body string) (srv *httptest.Server, checks *int32) {
+	t.Helper()
+
+	ts := tstest.NewServer()
+	t.Cleanup(ts.Close)
+
+	var count int32
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && atomic.AddInt32(&count, 1) <= n {
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, body)
+			return
+		}
+		ts.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &count
+}
+
+func TestWaitReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int32
+		status int
+		body   string
+	}{
+		{"ready", 0, 0, ""},
+		{"unavailable", 2, http.StatusServiceUnavailable, `{"message": "Not Ready or Lagging"}`},
+		{"not ok", 2, http.StatusOK, `{"ok": false}`},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			srv, checks := startingServer(t, test.n, test.status, test.body)
+
+			c := tsclient.NewLazy(srv.URL, tstest.APIKey)
+			if n := atomic.LoadInt32(checks); n != 0 {
+				t.Errorf("NewLazy made %v health checks, want 0", n)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := c.WaitReady(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := atomic.LoadInt32(checks); n != test.n+1 {
+				t.Errorf("made %v health checks, want %v", n, test.n+1)
+			}
+
+			// the client works once the server is ready
+			_, err = c.CreateCollection("terms", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWaitReadyNodes(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	srv, _ := startingServer(t, 1, http.StatusServiceUnavailable, "")
+
+	c, err := tsclient.NewNodesLazy([]string{srv.URL, down.URL}, tstest.APIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = c.WaitReady(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tsclient.NewNodesLazy(nil, tstest.APIKey)
+	if err == nil {
+		t.Error("got no error for a client without nodes")
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	starting, _ := startingServer(t, 1000, http.StatusServiceUnavailable, `{"message": "Not Ready or Lagging"}`)
+	notOK, _ := startingServer(t, 1000, http.StatusOK, `{"ok": false}`)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantState error
+	}{
+		{"unreachable", unreachable.URL, tsclient.ErrUnreachable},
+		{"unavailable", starting.URL, tsclient.ErrNotReady},
+		{"not ok", notOK.URL, tsclient.ErrNotReady},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := tsclient.NewLazy(test.url, tstest.APIKey)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+			defer cancel()
+			err := c.WaitReady(ctx)
+
+			if !errors.Is(err, test.wantState) {
+				t.Errorf("got %v, want %v", err, test.wantState)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want context.DeadlineExceeded", err)
+			}
+		})
+	}
+}
+
+func TestWaitReadyCanceled(t *testing.T) {
+	srv, checks := startingServer(t, 1000, http.StatusServiceUnavailable, "")
+	c := tsclient.NewLazy(srv.URL, tstest.APIKey)
+
+	checked := make(chan struct{}, 1000)
+	c.Use(tsclient.Hooks(nil, func(*tsclient.RequestInfo) {
+		checked <- struct{}{}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.WaitReady(ctx)
+	}()
+
+	// cancel while waiting between health checks
+	select {
+	case <-checked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no health check made")
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, tsclient.ErrNotReady) {
+			t.Errorf("got %v, want context.Canceled and ErrNotReady", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitReady didn't return after the context was canceled")
+	}
+
+	// an already canceled context returns after a single check
+	before := atomic.LoadInt32(checks)
+	err := c.WaitReady(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v for a canceled context, want context.Canceled", err)
+	}
+	if n := atomic.LoadInt32(checks) - before; n > 1 {
+		t.Errorf("made %v health checks with a canceled context, want at most 1", n)
+	}
+}
+
+func TestWaitReadyError(t *testing.T) {
+	srv, checks := startingServer(t, 1000, http.StatusUnauthorized, `{"message": "Forbidden - a valid x-typesense-api-key header must be sent."}`)
+	c := tsclient.NewLazy(srv.URL, "wrong")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// errors other than the server being unavailable are returned immediately
+	err := c.WaitReady(ctx)
+	if !errors.Is(err, tsclient.ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized", err)
+	}
+	if n := atomic.LoadInt32(checks); n != 1 {
+		t.Errorf("made %v health checks, want 1", n)
+	}
+}
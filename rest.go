@@ -1,9 +1,11 @@
 package tsclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"emperror.dev/errors"
 )
@@ -26,11 +28,33 @@ func (e apiError) Error() string {
 
 // Request makes a request returning a JSON body.
 func (c *Client) Request(method, endpoint string, opts ...RequestOption) (response []byte, err error) {
-	c.Debug("Request to %v (%v)", endpoint, method)
+	info, err := c.do(method, endpoint, false, opts...)
+	if info != nil && info.StatusCode == http.StatusBadRequest {
+		return info.Body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info.Body, nil
+}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, nil)
+// stream makes a request returning the unread response body.
+// The caller must close the body.
+func (c *Client) stream(method, endpoint string, opts ...RequestOption) (body io.ReadCloser, err error) {
+	info, err := c.do(method, endpoint, true, opts...)
 	if err != nil {
-		return
+		return nil, err
+	}
+	return info.body, nil
+}
+
+// do builds a request and sends it through the client's middleware.
+// If stream is true and the request succeeds, the response body is left unread in info.body.
+func (c *Client) do(method, endpoint string, stream bool, opts ...RequestOption) (*RequestInfo, error) {
+	// the URL is set to the URL of the chosen node when the request is sent
+	req, err := http.NewRequestWithContext(c.context(), method, c.nodes.nodes[0].url+endpoint, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, opt := range opts {
@@ -43,39 +67,235 @@ func (c *Client) Request(method, endpoint string, opts ...RequestOption) (respon
 	req.Header.Set("User-Agent", c.UserAgent)
 	req.Header["X-TYPESENSE-API-KEY"] = []string{c.apiKey}
 
-	resp, err := c.Client.Do(req)
+	info := &RequestInfo{
+		Method:   method,
+		Endpoint: endpoint,
+		Request:  req,
+		stream:   stream,
+	}
+
+	h := c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	c.log().Debug("request started", "method", method, "endpoint", endpoint)
+
+	err = h(info)
+	c.logRequest(info, err)
+	if err != nil && info.body != nil {
+		c.closeBody(info.body)
+		info.body = nil
+	}
+	return info, err
+}
+
+func (c *Client) logRequest(info *RequestInfo, err error) {
+	args := []interface{}{
+		"method", info.Method,
+		"endpoint", info.Endpoint,
+		"status", info.StatusCode,
+		"duration", info.Latency,
+		"retries", info.Retries,
+	}
+
 	if err != nil {
+		c.log().Debug("request failed", append(args, "error", err)...)
 		return
 	}
+	c.log().Debug("request finished", args...)
+}
+
+// send is the innermost Handler, sending the request with the HTTP client and retrying it if needed.
+func (c *Client) send(info *RequestInfo) error {
+	release, err := c.limits.acquire(info)
+	if err != nil {
+		info.Err = err
+		return err
+	}
 	defer func() {
-		err2 := resp.Body.Close()
-		if err2 != nil {
-			c.Debug("error closing response body: %v", err2)
+		// streamed requests are in flight until their body is closed
+		if info.body != nil {
+			info.body = &releaseOnClose{info.body, release}
+		} else {
+			release()
 		}
 	}()
 
-	response, err = io.ReadAll(resp.Body)
+	start := time.Now()
+	defer func() { info.Latency = time.Since(start) }()
+
+	backoff := c.RetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	tried := map[*node]bool{}
+
+	for {
+		err := c.attempt(info, tried)
+		if err == nil || info.Retries >= c.MaxRetries || !c.retryable(info, err) {
+			return err
+		}
+
+		c.log().Warn("request failed, retrying",
+			"method", info.Method, "endpoint", info.Endpoint, "status", info.StatusCode,
+			"attempt", info.Retries+1, "backoff", backoff, "error", err)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-info.Request.Context().Done():
+			t.Stop()
+			info.Err = info.Request.Context().Err()
+			return info.Err
+		}
+		backoff *= 2
+
+		if info.Request.GetBody != nil {
+			body, err := info.Request.GetBody()
+			if err != nil {
+				return err
+			}
+			info.Request.Body = body
+		}
+
+		info.Retries++
+		info.StatusCode = 0
+		info.Body = nil
+		info.Err = nil
+	}
+}
+
+// attempt sends the request to the next available node, or to multiple nodes if it is hedged.
+func (c *Client) attempt(info *RequestInfo, tried map[*node]bool) error {
+	if c.hedge.applies(info) && len(c.nodes.nodes) > 1 {
+		return c.sendHedged(info, tried)
+	}
+
+	nd, probe, err := c.nodes.pick(tried)
 	if err != nil {
-		return
+		info.Err = err
+		return err
 	}
+	tried[nd] = true
+	info.countSent(info.Request)
 
-	switch resp.StatusCode {
+	err = c.sendTo(nd, info)
+	nd.breaker.finish(info.Request.Context(), info, probe)
+	return err
+}
+
+// sendTo sends the request to the node.
+func (c *Client) sendTo(nd *node, info *RequestInfo) error {
+	u, err := nd.requestURL(info.Endpoint, info.Request.URL)
+	if err != nil {
+		info.Err = err
+		return err
+	}
+	info.Request.URL = u
+	info.Request.Host = ""
+
+	return c.sendOnce(info)
+}
+
+// retryable returns true if the request failed because the server was unreachable or unavailable,
+// it is safe to send again, and its body can be sent again.
+func (c *Client) retryable(info *RequestInfo, err error) bool {
+	if !idempotent(info.Method, info.Endpoint) {
+		return false
+	}
+
+	if info.Request.Body != nil && info.Request.GetBody == nil {
+		return false
+	}
+
+	if info.Request.Context().Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	return info.StatusCode == 0 || info.StatusCode == http.StatusServiceUnavailable
+}
+
+// idempotent returns true if sending the request more than once has the same effect as sending it once.
+// Inserts, imports and partial updates may be applied even if the response is lost, so they aren't.
+func idempotent(method, endpoint string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE":
+		return true
+	}
+	return isRead(method, endpoint)
+}
+
+// countSent counts req's body as sent for info.
+func (info *RequestInfo) countSent(req *http.Request) {
+	if req.ContentLength > 0 {
+		info.sent += req.ContentLength
+	}
+}
+
+func (c *Client) sendOnce(info *RequestInfo) error {
+	resp, err := c.Client.Do(info.Request)
+	if err != nil {
+		info.Err = err
+		return err
+	}
+
+	info.StatusCode = resp.StatusCode
+	err = statusError(resp.StatusCode)
+
+	if info.stream && err == nil {
+		info.body = resp.Body
+		return nil
+	}
+	defer c.closeBody(resp.Body)
+
+	body, readErr := io.ReadAll(resp.Body)
+	info.Body = body
+	if err == nil {
+		err = readErr
+	}
+
+	info.Err = err
+	return err
+}
+
+func (c *Client) closeBody(body io.ReadCloser) {
+	err := body.Close()
+	if err != nil {
+		c.log().Error("error closing response body", "error", err)
+	}
+}
+
+// withMessage adds the error message in a Typesense error response to err.
+func withMessage(err error, body []byte) error {
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Message == "" {
+		return err
+	}
+	return errors.WithMessage(err, resp.Message)
+}
+
+// statusError returns the error for the given status code, or nil if the request succeeded.
+func statusError(code int) error {
+	switch code {
 	case http.StatusOK, http.StatusNoContent, http.StatusCreated:
+		return nil
 	case http.StatusBadRequest:
-		return
+		return ErrBadRequest
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return ErrUnauthorized
 	case http.StatusNotFound:
-		return nil, ErrNotFound
+		return ErrNotFound
 	case http.StatusConflict:
-		return nil, ErrAlreadyExists
+		return ErrAlreadyExists
 	case http.StatusUnprocessableEntity:
-		return nil, ErrUnprocessable
+		return ErrUnprocessable
 	case http.StatusServiceUnavailable:
-		return nil, ErrUnavailable
+		return ErrUnavailable
 	default:
-		return nil, apiError(resp.StatusCode)
+		return apiError(code)
 	}
-
-	return response, err
 }
@@ -0,0 +1,162 @@
+package tsclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+)
+
+// unavailable returns a server responding with 503 Service Unavailable to the first n requests, and 200 OK after that.
+// Request bodies are recorded in bodies.
+func unavailable(t *testing.T, n int32) (srv *httptest.Server, requests *int32, bodies func() []string) {
+	t.Helper()
+
+	var (
+		count int32
+		mu    sync.Mutex
+		seen  []string
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		seen = append(seen, string(b))
+		mu.Unlock()
+
+		if atomic.AddInt32(&count, 1) <= n {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"message": "Not Ready or Lagging"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok": true}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &count, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		endpoint     string
+		body         interface{}
+		failures     int32
+		wantRequests int32
+		wantErr      error
+	}{
+		{"GET succeeds after retries", "GET", "/health", nil, 2, 3, nil},
+		{"GET gives up", "GET", "/health", nil, 5, 3, tsclient.ErrUnavailable},
+		{"PUT resends its body", "PUT", "/aliases/a", map[string]string{"collection_name": "b"}, 1, 2, nil},
+		{"DELETE is retried", "DELETE", "/collections/a", nil, 1, 2, nil},
+		{"multi_search is retried", "POST", "/multi_search", map[string]interface{}{"searches": []string{}}, 1, 2, nil},
+		{"POST is not retried", "POST", "/collections/a/documents", map[string]string{"id": "1"}, 1, 1, tsclient.ErrUnavailable},
+		{"PATCH is not retried", "PATCH", "/collections/a/documents/1", map[string]string{"id": "1"}, 1, 1, tsclient.ErrUnavailable},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			srv, requests, bodies := unavailable(t, test.failures)
+
+			c := tsclient.NewLazy(srv.URL, "key")
+			c.MaxRetries = 2
+			c.RetryBackoff = time.Millisecond
+
+			var retries int
+			c.Use(tsclient.Hooks(nil, func(info *tsclient.RequestInfo) { retries = info.Retries }))
+
+			_, err := c.Request(test.method, test.endpoint, tsclient.WithJSONBody(test.body))
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+
+			if got := atomic.LoadInt32(requests); got != test.wantRequests {
+				t.Errorf("server got %v requests, want %v", got, test.wantRequests)
+			}
+			if retries != int(test.wantRequests)-1 {
+				t.Errorf("RequestInfo.Retries = %v, want %v", retries, test.wantRequests-1)
+			}
+
+			b := bodies()
+			for _, body := range b[1:] {
+				if body != b[0] {
+					t.Errorf("retried with body %q, want %q", body, b[0])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryFailover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	up, requests, _ := unavailable(t, 0)
+
+	c, err := tsclient.NewNodesLazy([]string{down.URL, up.URL}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.MaxRetries = 1
+	c.RetryBackoff = time.Millisecond
+
+	// round-robin starts at the first node, which is unreachable
+	ok, err := c.Health()
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v, want the second node's response", ok, err)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("second node got %v requests, want 1", got)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	srv, requests, _ := unavailable(t, 100)
+
+	c := tsclient.NewLazy(srv.URL, "key")
+	c.MaxRetries = 5
+	c.RetryBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.WithContext(ctx).Health()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("waiting for the retry backoff wasn't interrupted by the context, took %v", d)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("server got %v requests, want 1", got)
+	}
+
+	// the original client doesn't use the context
+	c.MaxRetries = 0
+	_, err = c.Health()
+	if !errors.Is(err, tsclient.ErrUnavailable) {
+		t.Errorf("original client: got %v, want ErrUnavailable", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.WithContext(canceled).Health()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: got %v, want context.Canceled", err)
+	}
+}
@@ -1,11 +1,15 @@
 package tsclient
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
+	"emperror.dev/errors"
+
 	"github.com/termora/tsclient/utils/jsonutil"
 )
 
@@ -33,10 +37,12 @@ type SearchData struct {
 	Prefix []bool
 
 	// Filter conditions for refining your search results.
+	// GeoRadiusFilter and GeoPolygonFilter return conditions for geopoint fields.
 	FilterBy string
 
 	// A list of numerical fields and their corresponding sort orders that will be used for ordering your results.
 	// Up to 3 sort fields can be specified.
+	// Use (GeoSort).String to sort by distance to a point.
 	SortBy []string
 
 	// A list of fields that will be used for faceting your results on.
@@ -70,6 +76,9 @@ type SearchData struct {
 	IncludeFields []string
 	// list of fields from the document to exclude in the search result.
 	ExcludeFields []string
+	// Return fields with embeddings generated by Typesense in the search result.
+	// By default, Search adds them to ExcludeFields, unless IncludeFields is set.
+	IncludeEmbeddings bool
 
 	// list of fields that should be highlighted with snippetting.
 	// You can use this parameter to highlight fields that you don't query for, as well.
@@ -127,18 +136,169 @@ type SearchData struct {
 	// Maximum number of hits that can be fetched from the collection. Eg: 200
 	// page * per_page should be less than this number for the search request to return results.
 	LimitHits int
+
+	// Enable Typesense's server-side cache for this search.
+	UseCache bool
+	// Number of seconds the result is cached for by the server-side cache, if UseCache is true.
+	// Default: 60
+	CacheTTL int
+
+	// Nearest neighbor vector query, optionally combined with Query for hybrid search.
+	VectorQuery *VectorQuery
+
+	// Timeout in milliseconds for requests to remote embedding models while embedding the query.
+	// Default: 30000
+	RemoteEmbeddingTimeout int
+	// Number of times to try requests to remote embedding models while embedding the query.
+	// Default: 2
+	RemoteEmbeddingNumTries int
+}
+
+// VectorQuery is a nearest neighbor query on a float[] field.
+// Either Vector or ID must be set.
+type VectorQuery struct {
+	// The float[] field to query.
+	Field string
+
+	// The vector to search for.
+	Vector []float64
+	// Search for documents similar to the document with this ID, instead of Vector.
+	ID string
+
+	// Number of nearest neighbors to return.
+	// Default: per_page
+	K int
+
+	// Only return documents with a vector distance below this value.
+	DistanceThreshold *float64
+
+	// The weight given to the vector search in hybrid searches, between 0 and 1.
+	// The keyword search gets a weight of 1 - Alpha.
+	// Default: 0.3
+	Alpha *float64
+
+	// If the number of documents matched by the filter is below this value,
+	// Typesense does a brute force search instead of using the HNSW index.
+	FlatSearchCutoff int
+}
+
+// String returns the query in the format used by Typesense's vector_query parameter.
+func (q VectorQuery) String() string {
+	vec := make([]string, len(q.Vector))
+	for i, f := range q.Vector {
+		vec[i] = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	params := []string{"[" + strings.Join(vec, ", ") + "]"}
+
+	if q.ID != "" {
+		params = append(params, "id: "+q.ID)
+	}
+
+	if q.K != 0 {
+		params = append(params, "k: "+strconv.Itoa(q.K))
+	}
+
+	if q.DistanceThreshold != nil {
+		params = append(params, "distance_threshold: "+strconv.FormatFloat(*q.DistanceThreshold, 'g', -1, 64))
+	}
+
+	if q.Alpha != nil {
+		params = append(params, "alpha: "+strconv.FormatFloat(*q.Alpha, 'g', -1, 64))
+	}
+
+	if q.FlatSearchCutoff != 0 {
+		params = append(params, "flat_search_cutoff: "+strconv.Itoa(q.FlatSearchCutoff))
+	}
+
+	return q.Field + ":(" + strings.Join(params, ", ") + ")"
 }
 
 // Search searches the collection.
+// If the client-side search cache is enabled (see EnableSearchCache), results may be served from the cache.
+// If search coalescing is enabled (see EnableSearchCoalescing), identical concurrent searches share a single request.
+//
+// Embeddings generated by Typesense are excluded from the results unless data.IncludeEmbeddings is set.
+// The collection's embedding fields are looked up the first time it is searched,
+// and looked up again after this client creates or deletes the collection.
+// If the lookup fails (for example, with search-only API keys or aliases), nothing is excluded,
+// and the lookup is retried after a minute.
 func (c *Client) Search(collection string, data SearchData) (res SearchResult, err error) {
+	resp, err := c.search(collection, data)
+	// same as Request, 400 errors are returned as a successful response
+	if errors.Is(err, ErrBadRequest) {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(resp, &res)
+	return
+}
+
+// search returns the raw response for a search, using the cache and coalescer if they are enabled.
+// Unlike Search, it returns ErrBadRequest (with the response body) for 400 responses.
+func (c *Client) search(collection string, data SearchData) ([]byte, error) {
+	endpoint, err := collectionPath(collection, "documents", "search")
+	if err != nil {
+		return nil, err
+	}
+
+	if !data.IncludeEmbeddings && len(data.IncludeFields) == 0 {
+		fields := c.embeddings.get(c.context(), c, collection)
+		if len(fields) > 0 {
+			data.ExcludeFields = append(append([]string(nil), data.ExcludeFields...), fields...)
+		}
+	}
+
+	v := data.values()
+	key := collection + "?" + v.Encode()
+
+	if resp, ok := c.cache.get(collection, key); ok {
+		return resp, nil
+	}
+
+	if c.coalescer != nil {
+		return c.coalescer.do(c.context(), key, func(ctx context.Context) ([]byte, error) {
+			return c.WithContext(ctx).searchRequest(collection, endpoint, key, v)
+		})
+	}
+	return c.searchRequest(collection, endpoint, key, v)
+}
+
+// searchRequest makes a search request, adding the response to the cache if it is enabled.
+// For 400 responses, it returns the response body and ErrBadRequest.
+func (c *Client) searchRequest(collection, endpoint, key string, v url.Values) ([]byte, error) {
+	gen := c.cache.generation(collection)
+
+	info, err := c.do("GET", endpoint, false, WithURLValues(v))
+	if info != nil && info.StatusCode == http.StatusBadRequest {
+		// error responses shouldn't be cached
+		return info.Body, withMessage(err, info.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.put(collection, key, gen, info.Body)
+	return info.Body, nil
+}
+
+// values returns the query parameters for data.
+func (data SearchData) values() url.Values {
 	v := url.Values{
 		"q":                      {data.Query},
-		"query_by":               {strings.Join(data.QueryBy, ",")},
 		"prioritize_exact_match": {strconv.FormatBool(!data.NoPrioritizeExactMatch)},
 		"enable_overrides":       {strconv.FormatBool(!data.DisableOverrides)},
 		"pre_segmented_query":    {strconv.FormatBool(!data.NoPreSegmentedQuery)},
 	}
 
+	// not needed for wildcard (*) queries
+	if len(data.QueryBy) > 0 {
+		v["query_by"] = []string{strings.Join(data.QueryBy, ",")}
+	}
+
 	if len(data.Prefix) > 0 {
 		s := make([]string, len(data.Prefix))
 		for i, p := range data.Prefix {
@@ -195,6 +355,14 @@ func (c *Client) Search(collection string, data SearchData) (res SearchResult, e
 		v["group_limit"] = []string{strconv.Itoa(data.GroupLimit)}
 	}
 
+	if len(data.IncludeFields) > 0 {
+		v["include_fields"] = []string{strings.Join(data.IncludeFields, ",")}
+	}
+
+	if len(data.ExcludeFields) > 0 {
+		v["exclude_fields"] = []string{strings.Join(data.ExcludeFields, ",")}
+	}
+
 	if len(data.HighlightFields) > 0 {
 		v["highlight_fields"] = []string{strings.Join(data.HighlightFields, ",")}
 	}
@@ -239,18 +407,33 @@ func (c *Client) Search(collection string, data SearchData) (res SearchResult, e
 		v["num_typos"] = []string{strconv.Itoa(*data.NumTypos)}
 	}
 
-	resp, err := c.Request("GET", "/collections/"+collection+"/documents/search", WithURLValues(v))
-	if err != nil {
-		return
+	if data.VectorQuery != nil {
+		v["vector_query"] = []string{data.VectorQuery.String()}
 	}
 
-	err = json.Unmarshal(resp, &res)
-	return
+	if data.UseCache {
+		v["use_cache"] = []string{"true"}
+	}
+
+	if data.CacheTTL != 0 {
+		v["cache_ttl"] = []string{strconv.Itoa(data.CacheTTL)}
+	}
+
+	if data.RemoteEmbeddingTimeout != 0 {
+		v["remote_embedding_timeout_ms"] = []string{strconv.Itoa(data.RemoteEmbeddingTimeout)}
+	}
+
+	if data.RemoteEmbeddingNumTries != 0 {
+		v["remote_embedding_num_tries"] = []string{strconv.Itoa(data.RemoteEmbeddingNumTries)}
+	}
+
+	return v
 }
 
 // SearchResult is the result returned from a search.
 type SearchResult struct {
-	FacetCounts []int `json:"facet_counts"`
+	// Facet counts for each field in SearchData.FacetBy.
+	FacetCounts []FacetCount `json:"facet_counts"`
 
 	// Number of found documents
 	Found int `json:"found"`
@@ -263,6 +446,33 @@ type SearchResult struct {
 	Hits []SearchHit `json:"hits"`
 }
 
+// FacetCount is the facet counts for a single field in SearchResult.
+type FacetCount struct {
+	FieldName string `json:"field_name"`
+
+	Counts []FacetValueCount `json:"counts"`
+
+	Stats FacetStats `json:"stats"`
+}
+
+// FacetValueCount is the number of hits with a single facet value.
+type FacetValueCount struct {
+	Count       int    `json:"count"`
+	Value       string `json:"value"`
+	Highlighted string `json:"highlighted"`
+}
+
+// FacetStats are statistics for a faceted field.
+// Avg, Max, Min and Sum are only present for numerical fields.
+type FacetStats struct {
+	Avg float64 `json:"avg,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	Min float64 `json:"min,omitempty"`
+	Sum float64 `json:"sum,omitempty"`
+
+	TotalValues int `json:"total_values"`
+}
+
 // SearchHit is a single hit in SearchResult.
 // Document is raw JSON data, call UnmarshalTo to unmarshal it to a struct, or Map to unmarshal it to a map[string]interface{}.
 type SearchHit struct {
@@ -271,6 +481,23 @@ type SearchHit struct {
 	Highlights []Highlight `json:"highlights"`
 
 	TextMatch int `json:"text_match"`
+
+	// Distance between the document and the queried vector.
+	// Only present for vector searches.
+	VectorDistance *float64 `json:"vector_distance,omitempty"`
+
+	// Only present for hybrid searches.
+	HybridSearchInfo *HybridSearchInfo `json:"hybrid_search_info,omitempty"`
+
+	// Distance in meters between the document and the point given in a geo sort, keyed by field name.
+	// Only present for searches sorted by a geopoint field.
+	GeoDistanceMeters map[string]int `json:"geo_distance_meters,omitempty"`
+}
+
+// HybridSearchInfo is the ranking information for a hit in a hybrid (keyword and vector) search.
+type HybridSearchInfo struct {
+	// The combined score of the keyword and vector search ranks.
+	RankFusionScore float64 `json:"rank_fusion_score"`
 }
 
 // Highlight is a highlight in SearchResult.
@@ -0,0 +1,188 @@
+package tsclient
+
+import "emperror.dev/errors"
+
+// SearchIterator iterates over the hits of a search, fetching pages as needed.
+// Successive calls to Next step through the hits, in the style of bufio.Scanner:
+//
+//	it := client.SearchIterator("terms", tsclient.SearchData{Query: "plural", QueryBy: []string{"name"}})
+//	for it.Next() {
+//		hit := it.Hit()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// Iteration stops after all found hits have been returned, or after LimitHits hits if it is set.
+// Grouped searches (with GroupBy set) aren't supported, and fail with ErrGroupedSearch.
+type SearchIterator struct {
+	c          *Client
+	collection string
+	data       SearchData
+	prefetch   bool
+
+	started bool
+	res     SearchResult
+	// page number of res
+	page int
+	idx  int
+	// number of hits per page
+	pageSize int
+	// true if res is the last page
+	last bool
+
+	next chan searchPage
+	err  error
+}
+
+// ErrGroupedSearch is returned by SearchIterator for searches with GroupBy set.
+// Grouped hits are returned in groups rather than in SearchResult.Hits, so they can't be iterated over.
+const ErrGroupedSearch = errors.Sentinel("search iterator doesn't support grouped searches")
+
+type searchPage struct {
+	page int
+	res  SearchResult
+	err  error
+}
+
+// SearchIterator returns an iterator over the hits of a search.
+// No requests are made until Next is called.
+// If data.Page is set, iteration starts at that page.
+func (c *Client) SearchIterator(collection string, data SearchData) *SearchIterator {
+	if data.Page == 0 {
+		data.Page = 1
+	}
+
+	// Typesense's default per_page
+	pageSize := data.PerPage
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	it := &SearchIterator{
+		c:          c,
+		collection: collection,
+		data:       data,
+		pageSize:   pageSize,
+	}
+	if len(data.GroupBy) > 0 {
+		it.err = ErrGroupedSearch
+	}
+	return it
+}
+
+// Prefetch sets whether the iterator fetches the next page concurrently while the current page is being iterated over.
+// It must be called before the first call to Next.
+func (it *SearchIterator) Prefetch(prefetch bool) {
+	it.prefetch = prefetch
+}
+
+// Next advances the iterator to the next hit.
+// It returns false when there are no more hits or an error occurred.
+func (it *SearchIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.started && it.idx+1 < len(it.res.Hits) && !it.pastTotal(it.idx+1) {
+		it.idx++
+		return true
+	}
+
+	if it.started && it.last {
+		return false
+	}
+
+	page := it.fetch()
+	it.started = true
+	if page.err != nil {
+		it.err = page.err
+		return false
+	}
+
+	it.res = page.res
+	it.page = page.page
+	it.idx = 0
+
+	n := len(it.res.Hits)
+	if n == 0 {
+		it.last = true
+		return false
+	}
+	// every page but the last is full
+	it.last = n < it.pageSize || it.offset()+n >= it.total()
+	if it.pastTotal(0) {
+		it.last = true
+		return false
+	}
+
+	if it.prefetch && !it.last {
+		it.startPrefetch()
+	}
+
+	return true
+}
+
+// total returns the number of hits to return in total.
+func (it *SearchIterator) total() int {
+	if it.data.LimitHits > 0 && it.data.LimitHits < it.res.Found {
+		return it.data.LimitHits
+	}
+	return it.res.Found
+}
+
+// offset returns the number of hits before the current page.
+func (it *SearchIterator) offset() int {
+	return (it.page - 1) * it.pageSize
+}
+
+// pastTotal returns true if the hit at index i in the current page is past the total number of hits.
+func (it *SearchIterator) pastTotal(i int) bool {
+	return it.offset()+i >= it.total()
+}
+
+// fetch returns the next page, either from the prefetched page or by making a request.
+func (it *SearchIterator) fetch() searchPage {
+	if it.next != nil {
+		page := <-it.next
+		it.next = nil
+		return page
+	}
+
+	page := it.data.Page
+	res, err := it.c.Search(it.collection, it.data)
+	it.data.Page++
+	return searchPage{page, res, err}
+}
+
+func (it *SearchIterator) startPrefetch() {
+	ch := make(chan searchPage, 1)
+	it.next = ch
+
+	data := it.data
+	it.data.Page++
+
+	go func() {
+		res, err := it.c.Search(it.collection, data)
+		ch <- searchPage{data.Page, res, err}
+	}()
+}
+
+// Hit returns the current hit.
+func (it *SearchIterator) Hit() SearchHit {
+	if it.idx >= len(it.res.Hits) {
+		return SearchHit{}
+	}
+	return it.res.Hits[it.idx]
+}
+
+// Result returns the page the current hit is on.
+func (it *SearchIterator) Result() SearchResult {
+	return it.res
+}
+
+// Err returns the first error encountered by the iterator, if any.
+func (it *SearchIterator) Err() error {
+	return it.err
+}
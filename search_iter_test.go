@@ -0,0 +1,184 @@
+package tsclient_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+// pagedServer returns a server with found documents, returning per_page hits per page (10 by default).
+// Requests for failPage respond with 503 Service Unavailable.
+func pagedServer(t *testing.T, found, failPage int) (srv *httptest.Server, requests *int32) {
+	t.Helper()
+
+	var count int32
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/collections/terms/documents/search" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&count, 1)
+
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == failPage {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		perPage, _ := strconv.Atoi(q.Get("per_page"))
+		if perPage == 0 {
+			perPage = 10
+		}
+
+		hits := []map[string]interface{}{}
+		for i := (page - 1) * perPage; i < page*perPage && i < found; i++ {
+			hits = append(hits, map[string]interface{}{
+				"document": map[string]string{"id": strconv.Itoa(i)},
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"found": found,
+			"page":  page,
+			"hits":  hits,
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &count
+}
+
+func ids(from, to int) []string {
+	s := []string{}
+	for i := from; i < to; i++ {
+		s = append(s, strconv.Itoa(i))
+	}
+	return s
+}
+
+func TestSearchIterator(t *testing.T) {
+	tests := []struct {
+		name         string
+		found        int
+		data         tsclient.SearchData
+		failPage     int
+		wantIDs      []string
+		wantRequests int32
+		wantErr      error
+	}{
+		{"partial last page", 8, tsclient.SearchData{PerPage: 3}, 0, ids(0, 8), 3, nil},
+		{"full last page", 9, tsclient.SearchData{PerPage: 3}, 0, ids(0, 9), 3, nil},
+		{"default per_page", 25, tsclient.SearchData{}, 0, ids(0, 25), 3, nil},
+		{"start page", 8, tsclient.SearchData{Page: 2, PerPage: 3}, 0, ids(3, 8), 2, nil},
+		{"short start page", 8, tsclient.SearchData{Page: 3, PerPage: 3}, 0, ids(6, 8), 1, nil},
+		{"past the last page", 8, tsclient.SearchData{Page: 4, PerPage: 3}, 0, ids(0, 0), 1, nil},
+		{"limit hits", 8, tsclient.SearchData{PerPage: 3, LimitHits: 5}, 0, ids(0, 5), 2, nil},
+		{"no hits", 0, tsclient.SearchData{}, 0, ids(0, 0), 1, nil},
+		{"error", 8, tsclient.SearchData{PerPage: 3}, 2, ids(0, 3), 2, tsclient.ErrUnavailable},
+		{"grouped", 8, tsclient.SearchData{GroupBy: []string{"tags"}}, 0, ids(0, 0), 0, tsclient.ErrGroupedSearch},
+	}
+
+	for _, test := range tests {
+		test := test
+		for _, prefetch := range []bool{false, true} {
+			prefetch := prefetch
+			t.Run(test.name+" prefetch="+strconv.FormatBool(prefetch), func(t *testing.T) {
+				srv, requests := pagedServer(t, test.found, test.failPage)
+				c := tsclient.NewLazy(srv.URL, "key")
+
+				data := test.data
+				data.Query = "*"
+
+				it := c.SearchIterator("terms", data)
+				it.Prefetch(prefetch)
+
+				got := []string{}
+				for it.Next() {
+					var doc struct {
+						ID string `json:"id"`
+					}
+					err := it.Hit().UnmarshalTo(&doc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, doc.ID)
+				}
+
+				if !errors.Is(it.Err(), test.wantErr) {
+					t.Errorf("got error %v, want %v", it.Err(), test.wantErr)
+				}
+				if !reflect.DeepEqual(got, test.wantIDs) {
+					t.Errorf("got hits %v, want %v", got, test.wantIDs)
+				}
+				if n := atomic.LoadInt32(requests); n != test.wantRequests {
+					t.Errorf("server got %v requests, want %v", n, test.wantRequests)
+				}
+
+				// the iterator stays done
+				if it.Next() {
+					t.Error("Next returned true after iteration stopped")
+				}
+			})
+		}
+	}
+}
+
+func TestSearchIteratorFilter(t *testing.T) {
+	c, _ := tstest.New(t)
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []bulkDoc
+	for i := 0; i < 20; i++ {
+		name := "even"
+		if i%3 == 0 {
+			name = "third"
+		}
+		docs = append(docs, bulkDoc{ID: strconv.Itoa(i), Name: name})
+	}
+	_, err = c.Import("docs", "create", docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var searches int
+	c.Use(tsclient.Hooks(func(info *tsclient.RequestInfo) {
+		if info.Endpoint == "/collections/docs/documents/search" {
+			searches++
+		}
+	}, nil))
+
+	it := c.SearchIterator("docs", tsclient.SearchData{Query: "*", FilterBy: "name:=third", PerPage: 4})
+	got := []string{}
+	for it.Next() {
+		var doc bulkDoc
+		err := it.Hit().UnmarshalTo(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, doc.ID)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+
+	want := []string{"0", "3", "6", "9", "12", "15", "18"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got hits %v, want %v", got, want)
+	}
+	if searches != 2 {
+		t.Errorf("made %v searches, want 2", searches)
+	}
+}
@@ -0,0 +1,48 @@
+package tsclient_test
+
+import (
+	"testing"
+
+	"github.com/termora/tsclient"
+)
+
+func TestVectorQueryString(t *testing.T) {
+	zero, threshold, alpha := 0.0, 0.3, 0.8
+
+	tests := []struct {
+		name  string
+		query tsclient.VectorQuery
+		want  string
+	}{
+		{"vector", tsclient.VectorQuery{Field: "embedding", Vector: []float64{0.1, -2, 3.25}},
+			"embedding:([0.1, -2, 3.25])"},
+		{"empty vector", tsclient.VectorQuery{Field: "embedding"},
+			"embedding:([])"},
+		{"k", tsclient.VectorQuery{Field: "embedding", Vector: []float64{1, 2}, K: 100},
+			"embedding:([1, 2], k: 100)"},
+		{"distance threshold", tsclient.VectorQuery{Field: "embedding", Vector: []float64{1, 2}, DistanceThreshold: &threshold},
+			"embedding:([1, 2], distance_threshold: 0.3)"},
+		{"zero distance threshold", tsclient.VectorQuery{Field: "embedding", Vector: []float64{1, 2}, DistanceThreshold: &zero},
+			"embedding:([1, 2], distance_threshold: 0)"},
+		{"id", tsclient.VectorQuery{Field: "embedding", ID: "123"},
+			"embedding:([], id: 123)"},
+		{"id with k", tsclient.VectorQuery{Field: "embedding", ID: "123", K: 10},
+			"embedding:([], id: 123, k: 10)"},
+		{"hybrid", tsclient.VectorQuery{Field: "embedding", Alpha: &alpha, FlatSearchCutoff: 20},
+			"embedding:([], alpha: 0.8, flat_search_cutoff: 20)"},
+		{"all parameters", tsclient.VectorQuery{
+			Field:             "embedding",
+			Vector:            []float64{0.5},
+			K:                 5,
+			DistanceThreshold: &threshold,
+			Alpha:             &alpha,
+			FlatSearchCutoff:  20,
+		}, "embedding:([0.5], k: 5, distance_threshold: 0.3, alpha: 0.8, flat_search_cutoff: 20)"},
+	}
+
+	for _, test := range tests {
+		if got := test.query.String(); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package tsclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Tracer starts a span for every request made by a client.
+// Its shape matches OpenTelemetry's trace.Tracer, so adapting an OpenTelemetry tracer only takes a few lines,
+// without this package depending on OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a single traced request.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key-value pair added to a span.
+// Value is a string, int, int64, float64 or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracing returns a Middleware starting a span for every request.
+// The span is started from the client's context (see WithContext), and its context is passed on to the HTTP request.
+//
+// Spans are named "typesense.<family>", where family is one of search, import, export, documents, collections, health, or other,
+// and are annotated with the collection, endpoint, method, status code and retry count.
+// Search spans are also annotated with the query length, number of hits found, and the search time reported by Typesense.
+func Tracing(tracer Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(info *RequestInfo) error {
+			family := endpointFamily(info.Endpoint)
+
+			ctx, span := tracer.Start(info.Request.Context(), "typesense."+family)
+			defer span.End()
+
+			info.Request = info.Request.WithContext(ctx)
+
+			attrs := []Attribute{
+				{"typesense.endpoint", info.Endpoint},
+				{"http.method", info.Method},
+			}
+			if col := endpointCollection(info.Endpoint); col != "" {
+				attrs = append(attrs, Attribute{"typesense.collection", col})
+			}
+			if family == "search" && info.Request.URL.Query().Get("q") != "" {
+				attrs = append(attrs, Attribute{"typesense.query_length", len(info.Request.URL.Query().Get("q"))})
+			}
+			span.SetAttributes(attrs...)
+
+			err := next(info)
+
+			attrs = []Attribute{
+				{"http.status_code", info.StatusCode},
+				{"typesense.retries", info.Retries},
+			}
+
+			if family == "search" && err == nil {
+				var res struct {
+					Found      int `json:"found"`
+					SearchTime int `json:"search_time_ms"`
+				}
+				if json.Unmarshal(info.Body, &res) == nil {
+					attrs = append(attrs,
+						Attribute{"typesense.found", res.Found},
+						Attribute{"typesense.search_time_ms", res.SearchTime},
+					)
+				}
+			}
+			span.SetAttributes(attrs...)
+
+			if err != nil {
+				span.RecordError(err)
+			}
+			return err
+		}
+	}
+}
+
+// endpointCollection returns the collection name in endpoint, if any.
+func endpointCollection(endpoint string) string {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(parts) < 2 || parts[0] != "collections" {
+		return ""
+	}
+
+	col, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return parts[1]
+	}
+	return col
+}
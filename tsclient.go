@@ -2,9 +2,12 @@
 package tsclient
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"strings"
+	"time"
+
+	"emperror.dev/errors"
 )
 
 // VERSION is unlikely to ever be updated even as the library gets new releases
@@ -14,33 +17,86 @@ const VERSION = "0.1.0"
 type Client struct {
 	Client *http.Client
 
-	baseURL string
-	apiKey  string
+	nodes  *nodePool
+	apiKey string
 
-	// Debug is a debug logging function. No-op by default.
-	Debug func(tmpl string, args ...interface{})
+	// Logger logs requests, retries and errors. API keys are always redacted.
+	// Default: NopLogger
+	Logger Logger
 
 	UserAgent string
+
+	// MaxRetries is the number of times a request is retried if the server is unreachable or returns 503 Service Unavailable.
+	// Only idempotent requests (GET, PUT and DELETE requests, and multi-searches) are retried:
+	// POST and PATCH writes, such as inserts and imports, may have been applied even if no response was received.
+	// Requests with a body are only retried if the body can be read again.
+	// Default: 0
+	MaxRetries int
+	// RetryBackoff is the time to wait before retrying a request, doubled after every retry.
+	// Default: 100 milliseconds
+	RetryBackoff time.Duration
+
+	ctx        context.Context
+	middleware []Middleware
+	limits     *limits
+	cache      *searchCache
+	coalescer  *coalescer
+	hedge      *hedger
+	embeddings *embeddingFields
+}
+
+// WithContext returns a shallow copy of the client that uses ctx for all requests.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
 }
 
-// New creates a new Client and pings the server.
+// New creates a new Client and pings the server. Use NewLazy to skip the ping.
 func New(url, apiKey string) (*Client, error) {
-	c := &Client{
-		Client:    &http.Client{},
-		baseURL:   strings.TrimSuffix(url, "/"),
-		apiKey:    apiKey,
-		Debug:     func(string, ...interface{}) {},
-		UserAgent: "go/tsclient " + VERSION,
+	return NewNodes([]string{url}, apiKey)
+}
+
+// NewNodes creates a new Client sending requests to multiple nodes, and pings the cluster.
+// Requests are distributed between nodes in round-robin order, and retried requests fail over to the next node.
+// Set MaxRetries to at least len(urls)-1 to try every node before giving up.
+func NewNodes(urls []string, apiKey string) (*Client, error) {
+	c, err := newClient(urls, apiKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// we only care about if the request goes through at all
-	_, err := c.Health()
+	_, err = c.Health()
 	if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
+// newClient creates a new Client without pinging the server.
+func newClient(urls []string, apiKey string) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("at least one node URL is required")
+	}
+
+	return &Client{
+		Client:     &http.Client{},
+		nodes:      newNodePool(urls),
+		apiKey:     apiKey,
+		Logger:     NopLogger{},
+		UserAgent:  "go/tsclient " + VERSION,
+		embeddings: newEmbeddingFields(),
+	}, nil
+}
+
 // Health performs a health check on the server.
 func (c *Client) Health() (ok bool, err error) {
 	resp, err := c.Request("GET", "/health")
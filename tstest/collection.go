@@ -0,0 +1,389 @@
+package tstest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/termora/tsclient"
+)
+
+type document = map[string]interface{}
+
+type collection struct {
+	schema tsclient.Collection
+	seq    int64
+
+	docs map[string]document
+	// document IDs in insertion order
+	ids    []string
+	nextID int
+}
+
+func (c *collection) info() tsclient.Collection {
+	info := c.schema
+	info.NumDocuments = len(c.docs)
+	return info
+}
+
+func (s *Server) listCollections() []tsclient.Collection {
+	cols := make([]*collection, 0, len(s.collections))
+	for _, col := range s.collections {
+		cols = append(cols, col)
+	}
+
+	// most recent first
+	sort.Slice(cols, func(i, j int) bool { return cols[i].seq > cols[j].seq })
+
+	infos := make([]tsclient.Collection, len(cols))
+	for i, col := range cols {
+		infos[i] = col.info()
+	}
+	return infos
+}
+
+func (s *Server) createCollection(r *http.Request) (interface{}, *apiError) {
+	var schema tsclient.Collection
+	err := json.NewDecoder(r.Body).Decode(&schema)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "Bad JSON.")
+	}
+
+	if schema.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "Parameter `name` is required.")
+	}
+
+	if len(schema.Fields) == 0 {
+		return nil, errorf(http.StatusBadRequest, "Parameter `fields` is required.")
+	}
+
+	if _, ok := s.collections[schema.Name]; ok {
+		return nil, errorf(http.StatusConflict, "A collection with name `"+schema.Name+"` already exists.")
+	}
+
+	if aerr := checkEmbedFields(schema); aerr != nil {
+		return nil, aerr
+	}
+
+	s.seq++
+	col := &collection{
+		schema: schema,
+		seq:    s.seq,
+		docs:   map[string]document{},
+	}
+	s.collections[schema.Name] = col
+
+	return col.info(), nil
+}
+
+func (s *Server) getCollection(name string) (interface{}, *apiError) {
+	col, ok := s.collections[name]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Not Found")
+	}
+
+	return col.info(), nil
+}
+
+func (s *Server) deleteCollection(name string) (interface{}, *apiError) {
+	col, ok := s.collections[name]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Not Found")
+	}
+
+	delete(s.collections, name)
+	return col.info(), nil
+}
+
+func (c *collection) get(id string) (interface{}, *apiError) {
+	doc, ok := c.docs[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Could not find a document with id: "+id)
+	}
+	return doc, nil
+}
+
+func (c *collection) insert(r *http.Request) (interface{}, *apiError) {
+	var doc document
+	err := decodeDocument(r.Body, &doc)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "Bad JSON.")
+	}
+
+	return c.write(r.URL.Query().Get("action"), doc)
+}
+
+func (c *collection) update(id string, r *http.Request) (interface{}, *apiError) {
+	var doc document
+	err := decodeDocument(r.Body, &doc)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "Bad JSON.")
+	}
+
+	doc["id"] = id
+	return c.write("update", doc)
+}
+
+func (c *collection) delete(id string) (interface{}, *apiError) {
+	doc, ok := c.docs[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Could not find a document with id: "+id)
+	}
+
+	c.remove(id)
+	return doc, nil
+}
+
+func (c *collection) updateByFilter(r *http.Request) (interface{}, *apiError) {
+	f, err := parseFilter(r.URL.Query().Get("filter_by"))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, err.Error())
+	}
+	if f == nil {
+		return nil, errorf(http.StatusBadRequest, "Parameter `filter_by` must be provided.")
+	}
+
+	var partial document
+	err = decodeDocument(r.Body, &partial)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "Bad JSON.")
+	}
+	// IDs can't be changed
+	delete(partial, "id")
+
+	n := 0
+	for _, id := range c.ids {
+		if f.match(c.docs[id]) {
+			c.docs[id] = merge(c.docs[id], partial)
+			n++
+		}
+	}
+
+	return map[string]int{"num_updated": n}, nil
+}
+
+func (c *collection) deleteByFilter(r *http.Request) (interface{}, *apiError) {
+	f, err := parseFilter(r.URL.Query().Get("filter_by"))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, err.Error())
+	}
+	if f == nil {
+		return nil, errorf(http.StatusBadRequest, "Parameter `filter_by` must be provided.")
+	}
+
+	n := 0
+	for _, id := range append([]string(nil), c.ids...) {
+		if f.match(c.docs[id]) {
+			c.remove(id)
+			n++
+		}
+	}
+
+	return map[string]int{"num_deleted": n}, nil
+}
+
+// write creates or updates a document according to action.
+func (c *collection) write(action string, doc document) (document, *apiError) {
+	id, aerr := c.documentID(doc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	existing, exists := c.docs[id]
+
+	switch action {
+	case "", "create":
+		if exists {
+			return nil, errorf(http.StatusConflict, "A document with id "+id+" already exists.")
+		}
+	case "upsert":
+	case "update":
+		if !exists {
+			return nil, errorf(http.StatusNotFound, "Could not find a document with id: "+id)
+		}
+		doc = merge(existing, doc)
+	case "emplace":
+		if exists {
+			doc = merge(existing, doc)
+		}
+	default:
+		return nil, errorf(http.StatusBadRequest, "Invalid action.")
+	}
+
+	if aerr := c.embed(doc); aerr != nil {
+		return nil, aerr
+	}
+
+	if !exists {
+		c.ids = append(c.ids, id)
+	}
+	c.docs[id] = doc
+	return doc, nil
+}
+
+// documentID returns the document's ID, generating one if it doesn't have one.
+func (c *collection) documentID(doc document) (string, *apiError) {
+	v, ok := doc["id"]
+	if !ok {
+		for {
+			id := strconv.Itoa(c.nextID)
+			c.nextID++
+			if _, ok := c.docs[id]; !ok {
+				doc["id"] = id
+				return id, nil
+			}
+		}
+	}
+
+	id, ok := v.(string)
+	if !ok {
+		return "", errorf(http.StatusBadRequest, "Document's `id` field should be a string.")
+	}
+	if id == "" {
+		return "", errorf(http.StatusBadRequest, "The `id` of the resource cannot be empty.")
+	}
+	return id, nil
+}
+
+func (c *collection) remove(id string) {
+	delete(c.docs, id)
+	for i, v := range c.ids {
+		if v == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+func merge(a, b document) document {
+	out := document{}
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+func (c *collection) importDocuments(w http.ResponseWriter, r *http.Request) {
+	action := r.URL.Query().Get("action")
+
+	type result struct {
+		Success  bool   `json:"success"`
+		Error    string `json:"error,omitempty"`
+		Document string `json:"document,omitempty"`
+	}
+
+	var results []result
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(nil, 64*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var doc document
+		err := decodeDocument(bytes.NewReader(line), &doc)
+		if err != nil {
+			results = append(results, result{Error: "Bad JSON.", Document: string(line)})
+			continue
+		}
+
+		_, aerr := c.write(action, doc)
+		if aerr != nil {
+			results = append(results, result{Error: aerr.message, Document: string(line)})
+			continue
+		}
+
+		results = append(results, result{Success: true})
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for _, res := range results {
+		_ = enc.Encode(res)
+	}
+}
+
+func (c *collection) exportDocuments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	f, err := parseFilter(q.Get("filter_by"))
+	if err != nil {
+		writeError(w, errorf(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	include, exclude := fieldList(q, "include_fields"), fieldList(q, "exclude_fields")
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for _, id := range c.ids {
+		doc := c.docs[id]
+		if f != nil && !f.match(doc) {
+			continue
+		}
+
+		_ = enc.Encode(project(doc, include, exclude))
+	}
+}
+
+// project returns doc with only the included fields, or without the excluded fields.
+func project(doc document, include, exclude []string) document {
+	if len(include) == 0 && len(exclude) == 0 {
+		return doc
+	}
+
+	out := document{}
+	for k, v := range doc {
+		if len(include) > 0 && !contains(include, k) {
+			continue
+		}
+		if contains(exclude, k) {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func fieldList(q url.Values, key string) []string {
+	s := q.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	fields := strings.Split(s, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeDocument(r io.Reader, doc *document) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(doc)
+}
@@ -0,0 +1,102 @@
+package tstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/termora/tsclient"
+)
+
+// openAIURL is the default base URL for openai/ models, as in Typesense.
+const openAIURL = "https://api.openai.com"
+
+var embedClient = &http.Client{Timeout: 30 * time.Second}
+
+// checkEmbedFields returns an error if any of the schema's embedding fields can't be generated by the fake server.
+// Only remote OpenAI-compatible models are supported, as built-in models run inside Typesense.
+func checkEmbedFields(schema tsclient.Collection) *apiError {
+	for _, f := range schema.Fields {
+		if f.Embed == nil {
+			continue
+		}
+
+		if f.Type != "float[]" {
+			return errorf(http.StatusBadRequest, "Fields with the `embed` parameter can only be of type `float[]`.")
+		}
+		if len(f.Embed.From) == 0 {
+			return errorf(http.StatusBadRequest, "Property `embed.from` must contain at least one field.")
+		}
+		if !strings.HasPrefix(f.Embed.ModelConfig.ModelName, "openai/") {
+			return errorf(http.StatusBadRequest, "Model `"+f.Embed.ModelConfig.ModelName+"` is not supported by tstest, only openai/ models are.")
+		}
+		if f.Embed.ModelConfig.APIKey == "" {
+			return errorf(http.StatusBadRequest, "API key is required for OpenAI models.")
+		}
+	}
+	return nil
+}
+
+// embed generates the document's embedding fields, calling the fields' models.
+func (c *collection) embed(doc document) *apiError {
+	for _, f := range c.schema.Fields {
+		if f.Embed == nil {
+			continue
+		}
+
+		var text []string
+		for _, from := range f.Embed.From {
+			text = append(text, fieldStrings(doc[from])...)
+		}
+
+		vec, err := embedText(f.Embed.ModelConfig, f.Embed.ModelConfig.IndexingPrefix+strings.Join(text, " "))
+		if err != nil {
+			return err
+		}
+		doc[f.Name] = vec
+	}
+	return nil
+}
+
+// embedText calls an OpenAI-compatible embeddings API.
+func embedText(cfg tsclient.ModelConfig, text string) ([]float64, *apiError) {
+	base := cfg.URL
+	if base == "" {
+		base = openAIURL
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"model": strings.TrimPrefix(cfg.ModelName, "openai/"),
+		"input": text,
+	})
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(base, "/")+"/v1/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "OpenAI API error: "+err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+
+	resp, err := embedClient.Do(req)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "OpenAI API error: "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorf(http.StatusBadRequest, "OpenAI API error: "+resp.Status)
+	}
+
+	var res struct {
+		Data []struct {
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil || len(res.Data) == 0 {
+		return nil, errorf(http.StatusBadRequest, "OpenAI API error: invalid response")
+	}
+	return res.Data[0].Embedding, nil
+}
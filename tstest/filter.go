@@ -0,0 +1,241 @@
+package tstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// filter is a parsed filter_by expression.
+// Only conditions joined with && are supported.
+type filter []condition
+
+type condition struct {
+	field string
+	// one of ":", "=", "!=", ">", ">=", "<", "<="
+	op     string
+	values []string
+	// for range conditions such as field:[10..20]
+	ranges [][2]float64
+}
+
+// parseFilter parses a filter_by expression. It returns nil if s is empty.
+func parseFilter(s string) (filter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var f filter
+	for _, part := range splitTopLevel(s, "&&") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")") {
+			inner, err := parseFilter(part[1 : len(part)-1])
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, inner...)
+			continue
+		}
+
+		if strings.Contains(part, "||") {
+			return nil, fmt.Errorf("tstest: || is not supported in filter_by: %q", part)
+		}
+
+		cond, err := parseCondition(part)
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, cond)
+	}
+
+	return f, nil
+}
+
+func parseCondition(s string) (cond condition, err error) {
+	i := strings.Index(s, ":")
+	if i == -1 {
+		return cond, fmt.Errorf("tstest: could not parse filter condition %q", s)
+	}
+
+	cond.field = strings.TrimSpace(s[:i])
+	rest := strings.TrimSpace(s[i+1:])
+
+	cond.op = ":"
+	for _, op := range []string{"!=", ">=", "<=", "=", ">", "<"} {
+		if strings.HasPrefix(rest, op) {
+			cond.op = op
+			rest = strings.TrimSpace(rest[len(op):])
+			break
+		}
+	}
+
+	if strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") {
+		for _, v := range splitTopLevel(rest[1:len(rest)-1], ",") {
+			v = strings.TrimSpace(v)
+			if r := strings.SplitN(v, "..", 2); len(r) == 2 && !strings.HasPrefix(v, "`") {
+				lo, err1 := strconv.ParseFloat(strings.TrimSpace(r[0]), 64)
+				hi, err2 := strconv.ParseFloat(strings.TrimSpace(r[1]), 64)
+				if err1 == nil && err2 == nil {
+					cond.ranges = append(cond.ranges, [2]float64{lo, hi})
+					continue
+				}
+			}
+			cond.values = append(cond.values, unquote(v))
+		}
+		return cond, nil
+	}
+
+	cond.values = []string{unquote(rest)}
+	return cond, nil
+}
+
+// splitTopLevel splits s by sep, ignoring separators inside backticks, brackets and parentheses.
+func splitTopLevel(s, sep string) []string {
+	var (
+		parts []string
+		depth int
+		quote bool
+		start int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '`':
+			quote = !quote
+		case quote:
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '`' && s[len(s)-1] == '`' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func (f filter) match(doc document) bool {
+	for _, cond := range f {
+		if !cond.match(doc[cond.field]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond condition) match(v interface{}) bool {
+	if arr, ok := v.([]interface{}); ok {
+		if cond.op == "!=" {
+			for _, e := range arr {
+				if !cond.match(e) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, e := range arr {
+			if cond.match(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if v == nil {
+		return cond.op == "!="
+	}
+
+	for _, r := range cond.ranges {
+		if f, ok := toFloat(v); ok && f >= r[0] && f <= r[1] {
+			return true
+		}
+	}
+
+	matched := false
+	for _, want := range cond.values {
+		if cond.matchValue(v, want) {
+			matched = true
+			break
+		}
+	}
+
+	if cond.op == "!=" {
+		return !matched
+	}
+	return matched
+}
+
+func (cond condition) matchValue(v interface{}, want string) bool {
+	if f, ok := toFloat(v); ok {
+		w, err := strconv.ParseFloat(want, 64)
+		if err != nil {
+			return false
+		}
+
+		switch cond.op {
+		case ">":
+			return f > w
+		case ">=":
+			return f >= w
+		case "<":
+			return f < w
+		case "<=":
+			return f <= w
+		default:
+			return f == w
+		}
+	}
+
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v) == want
+	case string:
+		if cond.op == ":" && cond.field != "id" {
+			// token match: all tokens of want must be present in v
+			tokens := tokenize(v)
+			for _, t := range tokenize(want) {
+				if !contains(tokens, t) {
+					return false
+				}
+			}
+			return true
+		}
+		return v == want
+	}
+
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// tokenize splits s into lowercase words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
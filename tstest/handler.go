@@ -0,0 +1,145 @@
+package tstest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func errorf(status int, message string) *apiError {
+	return &apiError{status, message}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-TYPESENSE-API-KEY") != APIKey && r.URL.Query().Get("x-typesense-api-key") != APIKey {
+		writeError(w, errorf(http.StatusUnauthorized, "Forbidden - a valid `x-typesense-api-key` header must be sent."))
+		return
+	}
+
+	path, err := splitPath(r.URL)
+	if err != nil {
+		writeError(w, errorf(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		v    interface{}
+		aerr *apiError
+	)
+
+	switch {
+	case len(path) == 1 && path[0] == "health" && r.Method == "GET":
+		v = map[string]bool{"ok": true}
+
+	case len(path) == 1 && path[0] == "collections":
+		switch r.Method {
+		case "GET":
+			v = s.listCollections()
+		case "POST":
+			v, aerr = s.createCollection(r)
+		default:
+			aerr = errNotFound
+		}
+
+	case len(path) == 2 && path[0] == "collections":
+		switch r.Method {
+		case "GET":
+			v, aerr = s.getCollection(path[1])
+		case "DELETE":
+			v, aerr = s.deleteCollection(path[1])
+		default:
+			aerr = errNotFound
+		}
+
+	case len(path) >= 3 && path[0] == "collections" && path[2] == "documents":
+		col, ok := s.collections[path[1]]
+		if !ok {
+			aerr = errorf(http.StatusNotFound, "Collection not found")
+			break
+		}
+
+		// these write their own responses, as they aren't JSON objects
+		if len(path) == 4 && path[3] == "import" && r.Method == "POST" {
+			col.importDocuments(w, r)
+			return
+		}
+		if len(path) == 4 && path[3] == "export" && r.Method == "GET" {
+			col.exportDocuments(w, r)
+			return
+		}
+
+		v, aerr = col.serveDocuments(r, path[3:])
+
+	default:
+		aerr = errNotFound
+	}
+
+	if aerr != nil {
+		writeError(w, aerr)
+		return
+	}
+
+	status := http.StatusOK
+	if r.Method == "POST" && len(path) == 1 {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, v)
+}
+
+var errNotFound = errorf(http.StatusNotFound, "Not Found")
+
+func (col *collection) serveDocuments(r *http.Request, path []string) (interface{}, *apiError) {
+	switch {
+	case len(path) == 0 && r.Method == "POST":
+		return col.insert(r)
+	case len(path) == 0 && r.Method == "PATCH":
+		return col.updateByFilter(r)
+	case len(path) == 0 && r.Method == "DELETE":
+		return col.deleteByFilter(r)
+	case len(path) == 1 && path[0] == "search" && r.Method == "GET":
+		return col.search(r.URL.Query())
+	case len(path) == 1 && r.Method == "GET":
+		return col.get(path[0])
+	case len(path) == 1 && r.Method == "PATCH":
+		return col.update(path[0], r)
+	case len(path) == 1 && r.Method == "DELETE":
+		return col.delete(path[0])
+	}
+
+	return nil, errNotFound
+}
+
+// splitPath returns the unescaped segments of the URL's path.
+func splitPath(u *url.URL) ([]string, error) {
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i, seg := range segments {
+		s, err := url.PathUnescape(seg)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = s
+	}
+	return segments, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	writeJSON(w, err.status, map[string]string{"message": err.message})
+}
@@ -0,0 +1,328 @@
+package tstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// Recorder modes.
+const (
+	// Replay serves recorded responses, without making any requests.
+	Replay Mode = iota
+	// Record makes requests using the underlying transport and records them.
+	Record
+)
+
+// Redacted replaces secrets in recorded request and response bodies.
+const Redacted = "REDACTED"
+
+// RecordEnv is the environment variable checked by ModeFromEnv.
+const RecordEnv = "TSTEST_RECORD"
+
+// ModeFromEnv returns Record if the TSTEST_RECORD environment variable is set to a non-empty value, and Replay otherwise.
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return Record
+	}
+	return Replay
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// The canonical query string, with keys sorted and API keys removed.
+	Query string `json:"query,omitempty"`
+	// The canonical request body. JSON and JSONL bodies are re-encoded with sorted keys, and secrets are replaced with Redacted.
+	Body string `json:"body,omitempty"`
+
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records requests to a golden file, or replays previously recorded requests.
+// Set it as the Transport of (*tsclient.Client).Client.
+//
+// Requests are matched on their method, path, canonical query string and canonical body.
+// The API key header is never recorded. API key values sent to and returned by the /keys endpoints,
+// and the API keys of embedding models, are replaced with Redacted in both requests and responses.
+type Recorder struct {
+	// The transport used to make requests in Record mode.
+	// Default: http.DefaultTransport
+	Transport http.RoundTripper
+
+	mode Mode
+	path string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a new Recorder using the golden file at path.
+// In Replay mode, the file is read immediately.
+// In Record mode, Save must be called to write the recorded interactions.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		mode: mode,
+		path: path,
+	}
+
+	if mode == Record {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &r.interactions)
+	if err != nil {
+		return nil, err
+	}
+	r.used = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	in := Interaction{
+		Method: req.Method,
+		Path:   req.URL.EscapedPath(),
+		Query:  canonicalQuery(req.URL.Query()),
+		Body:   canonicalBody(req.URL.Path, body),
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, in)
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	in.Response = RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     recordedHeader(resp.Header),
+		Body:       scrubBody(req.URL.Path, respBody),
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay returns the first unused recorded response matching in.
+// If all matching responses have been used, the last one is returned again.
+func (r *Recorder) replay(req *http.Request, in Interaction) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, rec := range r.interactions {
+		if rec.Method != in.Method || rec.Path != in.Path || rec.Query != in.Query || rec.Body != in.Body {
+			continue
+		}
+
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+
+	if match == -1 {
+		return nil, fmt.Errorf("tstest: no recorded response for %v %v?%v", in.Method, in.Path, in.Query)
+	}
+	r.used[match] = true
+
+	rec := r.interactions[match].Response
+	header := rec.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the golden file. It does nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "\t")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+// canonicalQuery encodes q with sorted keys, without any API keys.
+func canonicalQuery(q url.Values) string {
+	for k := range q {
+		if strings.EqualFold(k, "x-typesense-api-key") {
+			delete(q, k)
+		}
+	}
+	return q.Encode()
+}
+
+// canonicalBody re-encodes JSON and JSONL bodies so that key order and whitespace don't matter,
+// replacing secrets sent to path with Redacted. Other bodies are returned unchanged.
+func canonicalBody(path string, body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+
+	fields := secretFields(path)
+	var lines []string
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	for dec.More() {
+		var v interface{}
+		if dec.Decode(&v) != nil {
+			return string(body)
+		}
+		scrub(v, fields)
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return string(body)
+		}
+		lines = append(lines, string(b))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// scrubBody returns a JSON response body from path with its secrets replaced with Redacted.
+// Bodies without secrets, and bodies that aren't JSON, are returned unchanged.
+func scrubBody(path string, body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if dec.Decode(&v) != nil || dec.More() || !scrub(v, secretFields(path)) {
+		return string(body)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+// secretFields returns the names of the JSON fields holding secrets in requests to and responses from path.
+func secretFields(path string) []string {
+	// embedding model API keys, in collection schemas
+	fields := []string{"api_key"}
+	if path == "/keys" || strings.HasPrefix(path, "/keys/") {
+		fields = append(fields, "value")
+	}
+	return fields
+}
+
+// scrub replaces the string values of fields in v and any values nested in it with Redacted,
+// and reports whether anything was replaced.
+func scrub(v interface{}, fields []string) (scrubbed bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if _, ok := e.(string); ok && isField(fields, k) {
+				v[k] = Redacted
+				scrubbed = true
+				continue
+			}
+			if scrub(e, fields) {
+				scrubbed = true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if scrub(e, fields) {
+				scrubbed = true
+			}
+		}
+	}
+	return scrubbed
+}
+
+func isField(fields []string, name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedHeader returns the response headers worth recording.
+func recordedHeader(h http.Header) http.Header {
+	out := http.Header{}
+	if v := h.Get("Content-Type"); v != "" {
+		out.Set("Content-Type", v)
+	}
+	return out
+}
@@ -0,0 +1,278 @@
+package tstest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/termora/tsclient"
+)
+
+type recordedDoc struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// recordServer returns a fake server that also creates API keys, returning secretKey as their value.
+func recordServer(t *testing.T, secretKey string) *httptest.Server {
+	t.Helper()
+
+	s := NewServer()
+	t.Cleanup(s.Close)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/keys" {
+			s.ServeHTTP(w, r)
+			return
+		}
+
+		var key map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&key)
+		key["id"] = 1
+		key["value"] = secretKey
+		writeJSON(w, http.StatusCreated, key)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// recordedRequests makes the requests recorded and replayed by TestRecorderRoundTrip.
+func recordedRequests(t *testing.T, c *tsclient.Client, customKey string) (key []byte, docs []recordedDoc) {
+	t.Helper()
+
+	_, err := c.CreateCollection("docs", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Import("docs", "create", []recordedDoc{{"1", "plural"}, {"2", "system"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Search("docs", tsclient.SearchData{Query: "plural", QueryBy: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hit := range res.Hits {
+		var doc recordedDoc
+		err = hit.UnmarshalTo(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+	}
+
+	key, err = c.Request("POST", "/keys", tsclient.WithJSONBody(map[string]interface{}{
+		"description": "search",
+		"actions":     []string{"documents:search"},
+		"value":       customKey,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, docs
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	const secret = "secret-key-value"
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	srv := recordServer(t, secret)
+	rec, err := NewRecorder(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tsclient.NewLazy(srv.URL, APIKey)
+	c.Client.Transport = rec
+
+	recordedKey, recordedDocs := recordedRequests(t, c, secret)
+	if !strings.Contains(string(recordedKey), secret) {
+		t.Errorf("got key %s while recording, want the real response", recordedKey)
+	}
+
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), secret) || strings.Contains(string(b), APIKey) {
+		t.Errorf("golden file contains a secret:\n%s", b)
+	}
+
+	// replaying doesn't make any requests
+	srv.Close()
+	rec, err = NewRecorder(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = tsclient.NewLazy(srv.URL, "another key")
+	c.Client.Transport = rec
+
+	key, docs := recordedRequests(t, c, secret)
+	if len(docs) != 1 || docs[0] != recordedDocs[0] {
+		t.Errorf("replayed search returned %v, want %v", docs, recordedDocs)
+	}
+
+	var keyResp struct {
+		Value string `json:"value"`
+	}
+	err = json.Unmarshal(key, &keyResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyResp.Value != Redacted {
+		t.Errorf("replayed key value is %q, want %q", keyResp.Value, Redacted)
+	}
+}
+
+func TestRecorderMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	err := os.WriteFile(path, []byte(`[
+		{"method": "GET", "path": "/collections/docs", "response": {"status_code": 200, "body": "{\"name\": \"docs\"}"}},
+		{"method": "GET", "path": "/collections/docs/documents/search", "query": "q=first",
+			"response": {"status_code": 200, "body": "first"}},
+		{"method": "GET", "path": "/collections/docs/documents/search", "query": "q=first",
+			"response": {"status_code": 200, "body": "second"}}
+	]`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := NewRecorder(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(u string) (string, error) {
+		t.Helper()
+
+		req := httptest.NewRequest("GET", u, nil)
+		req.RequestURI = ""
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			return "", err
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b), nil
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"match", "http://ts/collections/docs", `{"name": "docs"}`, false},
+		{"api key ignored", "http://ts/collections/docs/documents/search?q=first&x-typesense-api-key=key", "first", false},
+		{"next unused match", "http://ts/collections/docs/documents/search?q=first", "second", false},
+		{"last match reused", "http://ts/collections/docs/documents/search?q=first", "second", false},
+		{"different query", "http://ts/collections/docs/documents/search?q=other", "", true},
+		{"different path", "http://ts/collections/other", "", true},
+	}
+
+	for _, test := range tests {
+		got, err := get(test.url)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: got error %v, want error %v", test.name, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	// replay doesn't match requests with a different method or body
+	req := httptest.NewRequest("POST", "http://ts/collections/docs", strings.NewReader(`{}`))
+	req.RequestURI = ""
+	if _, err := rec.RoundTrip(req); err == nil {
+		t.Error("got a response for a request with a different method and body")
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"q=a&b=c", "b=c&q=a"},
+		{"q=a&X-TYPESENSE-API-KEY=secret&x-typesense-api-key=secret", "q=a"},
+		{"filter_by=a:%3E1&filter_by=b", "filter_by=a%3A%3E1&filter_by=b"},
+	}
+
+	for _, test := range tests {
+		q, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalQuery(q); got != test.want {
+			t.Errorf("canonicalQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalBody(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{"empty", "/collections", "", ""},
+		{"whitespace", "/collections", " \n", ""},
+		{"key order", "/collections", `{"b": 1, "a": {"d": 2, "c": 3}}`, `{"a":{"c":3,"d":2},"b":1}`},
+		{"numbers", "/collections", `{"a": 1.50, "b": 10000000000000000000001}`, `{"a":1.50,"b":10000000000000000000001}`},
+		{"jsonl", "/collections/docs/documents/import", "{\"id\": \"1\", \"b\": 2}\n\n{\"id\": \"2\"}\n", "{\"b\":2,\"id\":\"1\"}\n{\"id\":\"2\"}"},
+		{"not json", "/collections", "not json", "not json"},
+		{"partly json", "/collections", `{"id": "1"} not json`, `{"id": "1"} not json`},
+		{"model api key", "/collections", `{"fields": [{"embed": {"model_config": {"api_key": "sk-secret"}}}]}`,
+			`{"fields":[{"embed":{"model_config":{"api_key":"REDACTED"}}}]}`},
+		{"key value", "/keys", `{"value": "secret", "actions": ["*"]}`, `{"actions":["*"],"value":"REDACTED"}`},
+		{"value outside keys", "/collections/docs/documents", `{"value": "kept"}`, `{"value":"kept"}`},
+	}
+
+	for _, test := range tests {
+		if got := canonicalBody(test.path, []byte(test.body)); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestScrubBody(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{"no secrets", "/keys", `{"id": 1, "value_prefix": "abcd"}`, `{"id": 1, "value_prefix": "abcd"}`},
+		{"created key", "/keys", `{"id": 1, "value": "secret"}`, `{"id":1,"value":"REDACTED"}`},
+		{"key list", "/keys", `{"keys": [{"id": 1, "value": "a"}, {"id": 2, "value": "b"}]}`,
+			`{"keys":[{"id":1,"value":"REDACTED"},{"id":2,"value":"REDACTED"}]}`},
+		{"single key", "/keys/1", `{"id": 1, "value": "secret"}`, `{"id":1,"value":"REDACTED"}`},
+		{"schema", "/collections/docs", `{"fields": [{"embed": {"model_config": {"api_key": "sk-secret"}}}]}`,
+			`{"fields":[{"embed":{"model_config":{"api_key":"REDACTED"}}}]}`},
+		{"document", "/collections/docs/documents/1", `{"id": "1", "value": "kept"}`, `{"id": "1", "value": "kept"}`},
+		{"not json", "/keys", "value", "value"},
+	}
+
+	for _, test := range tests {
+		if got := scrubBody(test.path, []byte(test.body)); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
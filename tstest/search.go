@@ -0,0 +1,444 @@
+package tstest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type searchHit struct {
+	doc        document
+	textMatch  int
+	highlights []highlight
+}
+
+type highlight struct {
+	Field         string   `json:"field"`
+	MatchedTokens []string `json:"matched_tokens"`
+	Snippet       string   `json:"snippet"`
+}
+
+type searchResponse struct {
+	FacetCounts []facetCount  `json:"facet_counts"`
+	Found       int           `json:"found"`
+	OutOf       int           `json:"out_of"`
+	Page        int           `json:"page"`
+	SearchTime  int           `json:"search_time_ms"`
+	Hits        []hitResponse `json:"hits"`
+}
+
+type facetCount struct {
+	FieldName string            `json:"field_name"`
+	Counts    []facetValueCount `json:"counts"`
+	Stats     facetStats        `json:"stats"`
+}
+
+type facetValueCount struct {
+	Count       int    `json:"count"`
+	Value       string `json:"value"`
+	Highlighted string `json:"highlighted"`
+}
+
+type facetStats struct {
+	Avg         float64 `json:"avg,omitempty"`
+	Max         float64 `json:"max,omitempty"`
+	Min         float64 `json:"min,omitempty"`
+	Sum         float64 `json:"sum,omitempty"`
+	TotalValues int     `json:"total_values"`
+}
+
+type hitResponse struct {
+	Document   document    `json:"document"`
+	Highlights []highlight `json:"highlights"`
+	TextMatch  int         `json:"text_match"`
+}
+
+func (c *collection) search(q url.Values) (interface{}, *apiError) {
+	start := time.Now()
+
+	if _, ok := q["q"]; !ok {
+		return nil, errorf(http.StatusBadRequest, "Parameter `q` is required.")
+	}
+
+	query := q.Get("q")
+	queryBy := fieldList(q, "query_by")
+	if query != "*" && len(queryBy) == 0 {
+		return nil, errorf(http.StatusBadRequest, "Parameter `query_by` is required.")
+	}
+
+	f, err := parseFilter(q.Get("filter_by"))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, err.Error())
+	}
+
+	page, aerr := intParam(q, "page", 1)
+	if aerr != nil {
+		return nil, aerr
+	}
+	perPage, aerr := intParam(q, "per_page", 10)
+	if aerr != nil {
+		return nil, aerr
+	}
+	limitHits, aerr := intParam(q, "limit_hits", 0)
+	if aerr != nil {
+		return nil, aerr
+	}
+	maxFacetValues, aerr := intParam(q, "max_facet_values", 10)
+	if aerr != nil {
+		return nil, aerr
+	}
+	if page < 1 || perPage < 0 || perPage > 250 {
+		return nil, errorf(http.StatusUnprocessableEntity, "Invalid page or per_page.")
+	}
+
+	// the last token is matched as a prefix unless prefix=false
+	prefix := q.Get("prefix") != "false"
+
+	var tokens []string
+	if query != "*" {
+		tokens = tokenize(query)
+	}
+
+	var hits []searchHit
+	for _, id := range c.ids {
+		doc := c.docs[id]
+		if f != nil && !f.match(doc) {
+			continue
+		}
+
+		hit, ok := matchDocument(doc, queryBy, tokens, prefix)
+		if ok {
+			hits = append(hits, hit)
+		}
+	}
+
+	aerr = c.sortHits(hits, q.Get("sort_by"))
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	resp := searchResponse{
+		FacetCounts: facetCounts(hits, fieldList(q, "facet_by"), maxFacetValues),
+		Found:       len(hits),
+		OutOf:       len(c.docs),
+		Page:        page,
+		Hits:        []hitResponse{},
+	}
+
+	if limitHits > 0 && (page-1)*perPage >= limitHits {
+		return nil, errorf(http.StatusUnprocessableEntity, "Only upto "+strconv.Itoa(limitHits)+" hits can be fetched.")
+	}
+
+	include, exclude := fieldList(q, "include_fields"), fieldList(q, "exclude_fields")
+
+	for i := (page - 1) * perPage; i < page*perPage && i < len(hits); i++ {
+		if limitHits > 0 && i >= limitHits {
+			break
+		}
+
+		hl := hits[i].highlights
+		if hl == nil {
+			hl = []highlight{}
+		}
+
+		resp.Hits = append(resp.Hits, hitResponse{
+			Document:   project(hits[i].doc, include, exclude),
+			Highlights: hl,
+			TextMatch:  hits[i].textMatch,
+		})
+	}
+
+	resp.SearchTime = int(time.Since(start).Milliseconds())
+	return resp, nil
+}
+
+// matchDocument returns whether every token in tokens matches a word in one of the fields.
+func matchDocument(doc document, fields, tokens []string, prefix bool) (hit searchHit, ok bool) {
+	hit.doc = doc
+	if len(tokens) == 0 {
+		return hit, true
+	}
+
+	matched := make([]bool, len(tokens))
+
+	for _, field := range fields {
+		var hl highlight
+
+		for _, s := range fieldStrings(doc[field]) {
+			words := tokenize(s)
+
+			for i, t := range tokens {
+				isPrefix := prefix && i == len(tokens)-1
+
+				for _, w := range words {
+					if w == t || (isPrefix && strings.HasPrefix(w, t)) {
+						matched[i] = true
+						hit.textMatch++
+						if !contains(hl.MatchedTokens, w) {
+							hl.MatchedTokens = append(hl.MatchedTokens, w)
+						}
+					}
+				}
+			}
+
+			if len(hl.MatchedTokens) > 0 && hl.Snippet == "" {
+				hl.Snippet = markWords(s, hl.MatchedTokens)
+			}
+		}
+
+		if len(hl.MatchedTokens) > 0 {
+			hl.Field = field
+			hit.highlights = append(hit.highlights, hl)
+		}
+	}
+
+	for _, m := range matched {
+		if !m {
+			return hit, false
+		}
+	}
+	return hit, true
+}
+
+// markWords surrounds words in s that are in tokens with <mark> tags.
+func markWords(s string, tokens []string) string {
+	var b strings.Builder
+
+	for _, w := range strings.Fields(s) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+
+		t := tokenize(w)
+		if len(t) == 1 && contains(tokens, t[0]) {
+			b.WriteString("<mark>" + w + "</mark>")
+		} else {
+			b.WriteString(w)
+		}
+	}
+
+	return b.String()
+}
+
+// fieldStrings returns the string values of a string or string[] field.
+func fieldStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var s []string
+		for _, e := range v {
+			if str, ok := e.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+func (c *collection) sortHits(hits []searchHit, sortBy string) *apiError {
+	type sortField struct {
+		field string
+		desc  bool
+	}
+
+	var fields []sortField
+	if sortBy != "" {
+		for _, s := range strings.Split(sortBy, ",") {
+			s = strings.TrimSpace(s)
+
+			parts := strings.SplitN(s, ":", 2)
+			if len(parts) != 2 || (parts[1] != "asc" && parts[1] != "desc") {
+				return errorf(http.StatusBadRequest, "Bad syntax for sorting field `"+s+"`")
+			}
+
+			fields = append(fields, sortField{parts[0], parts[1] == "desc"})
+		}
+	} else {
+		fields = append(fields, sortField{"_text_match", true})
+		if c.schema.DefaultSortingField != "" {
+			fields = append(fields, sortField{c.schema.DefaultSortingField, true})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, f := range fields {
+			var cmp int
+			if f.field == "_text_match" {
+				cmp = compareInts(hits[i].textMatch, hits[j].textMatch)
+			} else {
+				cmp = compareValues(hits[i].doc[f.field], hits[j].doc[f.field])
+			}
+
+			if cmp == 0 {
+				continue
+			}
+			if f.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	return nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareValues compares two numbers, strings or bools. Missing values sort before all other values.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(sa, sb)
+	}
+
+	ba, aok := a.(bool)
+	bb, bok := b.(bool)
+	if aok && bok && ba != bb {
+		if ba {
+			return 1
+		}
+		return -1
+	}
+
+	return 0
+}
+
+func facetCounts(hits []searchHit, fields []string, maxValues int) []facetCount {
+	counts := []facetCount{}
+
+	for _, field := range fields {
+		fc := facetCount{FieldName: field}
+
+		values := map[string]int{}
+		var (
+			numeric = true
+			lo, hi  float64
+			sum     float64
+			n       int
+		)
+
+		for _, hit := range hits {
+			vals, ok := hit.doc[field].([]interface{})
+			if !ok {
+				vals = []interface{}{hit.doc[field]}
+			}
+
+			for _, v := range vals {
+				if v == nil {
+					continue
+				}
+
+				s := stringValue(v)
+				values[s]++
+
+				f, ok := toFloat(v)
+				if !ok {
+					numeric = false
+					continue
+				}
+				if n == 0 || f < lo {
+					lo = f
+				}
+				if n == 0 || f > hi {
+					hi = f
+				}
+				sum += f
+				n++
+			}
+		}
+
+		for v, count := range values {
+			fc.Counts = append(fc.Counts, facetValueCount{
+				Count:       count,
+				Value:       v,
+				Highlighted: v,
+			})
+		}
+
+		sort.Slice(fc.Counts, func(i, j int) bool {
+			if fc.Counts[i].Count != fc.Counts[j].Count {
+				return fc.Counts[i].Count > fc.Counts[j].Count
+			}
+			return fc.Counts[i].Value < fc.Counts[j].Value
+		})
+
+		fc.Stats.TotalValues = len(fc.Counts)
+		if maxValues > 0 && len(fc.Counts) > maxValues {
+			fc.Counts = fc.Counts[:maxValues]
+		}
+
+		if numeric && n > 0 {
+			fc.Stats.Min, fc.Stats.Max, fc.Stats.Sum = lo, hi, sum
+			fc.Stats.Avg = sum / float64(n)
+		}
+
+		counts = append(counts, fc)
+	}
+
+	return counts
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
+
+func intParam(q url.Values, key string, def int) (int, *apiError) {
+	s := q.Get(key)
+	if s == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "Parameter `"+key+"` must be an integer.")
+	}
+	return i, nil
+}
@@ -0,0 +1,69 @@
+// Package tstest implements a fake, in-memory Typesense server for use in tests.
+//
+// The fake server supports collections, documents, importing and exporting documents,
+// and a subset of searching: prefix matching on query_by fields, simple filter_by conditions,
+// sort_by, facet_by and pagination. It does not implement typo tolerance, ranking beyond
+// counting matched tokens, vector search, or any of the other endpoints.
+//
+// Embedding fields are generated by calling the field's model, which must be an openai/ model.
+// Set the model's URL to an OpenAI-compatible server (such as an httptest.Server) to use a stand-in embedding model.
+package tstest
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/termora/tsclient"
+)
+
+// APIKey is the API key accepted by the fake server.
+const APIKey = "tstest"
+
+// Server is a fake Typesense server.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections map[string]*collection
+	// incremented for every created collection, used for sorting collections by creation date
+	seq int64
+}
+
+// NewServer starts a new fake Typesense server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		collections: map[string]*collection{},
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewClient returns a new client for the server.
+func (s *Server) NewClient() (*tsclient.Client, error) {
+	return tsclient.New(s.URL, APIKey)
+}
+
+// New starts a new fake Typesense server, returning a client for it.
+// The server is closed when the test finishes.
+func New(tb testing.TB) (*tsclient.Client, *Server) {
+	tb.Helper()
+
+	s := NewServer()
+	tb.Cleanup(s.Close)
+
+	c, err := s.NewClient()
+	if err != nil {
+		tb.Fatalf("creating client for fake Typesense server: %v", err)
+	}
+
+	return c, s
+}
+
+// Reset deletes all collections.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.collections = map[string]*collection{}
+	s.mu.Unlock()
+}
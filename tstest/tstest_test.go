@@ -0,0 +1,393 @@
+package tstest_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/termora/tsclient"
+	"github.com/termora/tsclient/tstest"
+)
+
+type term struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	Num    int      `json:"num"`
+	Active bool     `json:"active"`
+}
+
+var terms = []term{
+	{"1", "Plural system", []string{"plural", "general"}, 10, true},
+	{"2", "Plurality", []string{"plural"}, 20, true},
+	{"3", "Singlet", []string{"general"}, 30, false},
+	{"4", "Median system", []string{"plural", "median"}, 40, true},
+	{"5", "Headmate", nil, 50, false},
+}
+
+// newTerms returns a client for a fake server with a terms collection containing terms.
+func newTerms(t *testing.T) (*tsclient.Client, *tstest.Server) {
+	t.Helper()
+
+	c, s := tstest.New(t)
+
+	_, err := c.CreateCollection("terms", "num", []tsclient.CreateFieldData{
+		{Name: "name", Type: "string"},
+		{Name: "tags", Type: "string[]", Facet: true},
+		{Name: "num", Type: "int32", Facet: true},
+		{Name: "active", Type: "bool"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := c.Import("terms", "create", terms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range ok {
+		if !ok {
+			t.Fatalf("importing term %v failed", terms[i].ID)
+		}
+	}
+
+	return c, s
+}
+
+func hitIDs(t *testing.T, res tsclient.SearchResult) []string {
+	t.Helper()
+
+	ids := []string{}
+	for _, hit := range res.Hits {
+		var doc term
+		err := hit.UnmarshalTo(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func TestCollections(t *testing.T) {
+	c, s := tstest.New(t)
+
+	for _, name := range []string{"a", "b"} {
+		_, err := c.CreateCollection(name, "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := c.CreateCollection("a", "", []tsclient.CreateFieldData{{Name: "name", Type: "string"}})
+	if !errors.Is(err, tsclient.ErrAlreadyExists) {
+		t.Errorf("creating duplicate collection: got %v, want ErrAlreadyExists", err)
+	}
+
+	cols, err := c.Collections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 2 || cols[0].Name != "b" || cols[1].Name != "a" {
+		t.Errorf("collections not sorted by most recent first: %+v", cols)
+	}
+
+	col, err := c.Collection("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(col.Fields) != 1 || col.Fields[0].Name != "name" || !col.Fields[0].Index {
+		t.Errorf("unexpected schema: %+v", col)
+	}
+
+	_, err = c.DeleteCollection("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Collection("a")
+	if !errors.Is(err, tsclient.ErrNotFound) {
+		t.Errorf("getting deleted collection: got %v, want ErrNotFound", err)
+	}
+
+	s.Reset()
+	cols, err = c.Collections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 0 {
+		t.Errorf("collections left after Reset: %+v", cols)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	c, _ := newTerms(t)
+
+	var doc term
+	id, err := c.Document("terms", "2", &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "2" || !reflect.DeepEqual(doc, terms[1]) {
+		t.Errorf("got %v %+v, want %+v", id, doc, terms[1])
+	}
+
+	err = c.Insert("terms", terms[0], nil)
+	if !errors.Is(err, tsclient.ErrAlreadyExists) {
+		t.Errorf("inserting duplicate document: got %v, want ErrAlreadyExists", err)
+	}
+
+	err = c.UpdateDocument("terms", "2", map[string]interface{}{"num": 21}, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Num != 21 || doc.Name != "Plurality" {
+		t.Errorf("update didn't merge fields: %+v", doc)
+	}
+
+	err = c.Upsert("terms", term{ID: "6", Name: "Fictive"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.DeleteDocument("terms", "6", &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "Fictive" {
+		t.Errorf("deleted document not returned: %+v", doc)
+	}
+
+	_, err = c.Document("terms", "6", nil)
+	if !errors.Is(err, tsclient.ErrNotFound) {
+		t.Errorf("getting deleted document: got %v, want ErrNotFound", err)
+	}
+
+	n, err := c.UpdateQuery("terms", "tags:=general", map[string]interface{}{"active": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("updated %v documents, want 2", n)
+	}
+
+	n, err = c.DeleteQuery("terms", "active:=true", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("deleted %v documents, want 4", n)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	c, _ := newTerms(t)
+
+	res, err := c.Search("terms", tsclient.SearchData{Query: "plur", QueryBy: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both hits match one token, so the default sorting field (num, descending) breaks the tie
+	if got, want := hitIDs(t, res), []string{"2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got hits %v, want %v", got, want)
+	}
+	if res.Found != 2 || res.OutOf != len(terms) {
+		t.Errorf("found = %v, out_of = %v", res.Found, res.OutOf)
+	}
+
+	hl := res.Hits[1].Highlights
+	if len(hl) != 1 || hl[0].Field != "name" || hl[0].Snippet != "<mark>Plural</mark> system" {
+		t.Errorf("unexpected highlights: %+v", hl)
+	}
+
+	res, err = c.Search("terms", tsclient.SearchData{Query: "plur", QueryBy: []string{"name"}, Prefix: []bool{false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found != 0 {
+		t.Errorf("prefix=false matched %v documents", res.Found)
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	c, _ := newTerms(t)
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"num:>20", []string{"3", "4", "5"}},
+		{"num:<=20", []string{"1", "2"}},
+		{"num:[10..20, 50]", []string{"1", "2", "5"}},
+		{"active:true", []string{"1", "2", "4"}},
+		{"tags:=plural", []string{"1", "2", "4"}},
+		{"tags:!=plural", []string{"3", "5"}},
+		{"tags:[median, general]", []string{"1", "3", "4"}},
+		{"name:system && num:>10", []string{"4"}},
+		{"(active:true) && tags:=general", []string{"1"}},
+		{"id:[`1`, `3`]", []string{"1", "3"}},
+		{"name:`plural system`", []string{"1"}},
+	}
+
+	for _, test := range tests {
+		res, err := c.Search("terms", tsclient.SearchData{Query: "*", FilterBy: test.filter})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := hitIDs(t, res)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("filter %q: got %v, want %v", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestSearchSort(t *testing.T) {
+	c, _ := newTerms(t)
+
+	tests := []struct {
+		sortBy []string
+		want   []string
+	}{
+		{nil, []string{"5", "4", "3", "2", "1"}},
+		{[]string{"num:asc"}, []string{"1", "2", "3", "4", "5"}},
+		{[]string{"active:desc", "num:asc"}, []string{"1", "2", "4", "3", "5"}},
+		{[]string{"name:asc"}, []string{"5", "4", "1", "2", "3"}},
+	}
+
+	for _, test := range tests {
+		res, err := c.Search("terms", tsclient.SearchData{Query: "*", SortBy: test.sortBy})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := hitIDs(t, res); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sort_by %v: got %v, want %v", test.sortBy, got, test.want)
+		}
+	}
+}
+
+func TestSearchFacets(t *testing.T) {
+	c, _ := newTerms(t)
+
+	res, err := c.Search("terms", tsclient.SearchData{
+		Query:          "*",
+		FilterBy:       "num:<50",
+		FacetBy:        []string{"tags", "num"},
+		MaxFacetValues: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.FacetCounts) != 2 {
+		t.Fatalf("got %v facet counts, want 2", len(res.FacetCounts))
+	}
+
+	tags := res.FacetCounts[0]
+	want := []tsclient.FacetValueCount{
+		{Count: 3, Value: "plural", Highlighted: "plural"},
+		{Count: 2, Value: "general", Highlighted: "general"},
+	}
+	if tags.FieldName != "tags" || !reflect.DeepEqual(tags.Counts, want) {
+		t.Errorf("tags facet: got %+v, want %+v", tags, want)
+	}
+	if tags.Stats.TotalValues != 3 {
+		t.Errorf("tags total values = %v, want 3", tags.Stats.TotalValues)
+	}
+
+	num := res.FacetCounts[1].Stats
+	if num.Min != 10 || num.Max != 40 || num.Sum != 100 || num.Avg != 25 {
+		t.Errorf("unexpected num stats: %+v", num)
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	c, _ := newTerms(t)
+
+	var ids []string
+	for page := 1; ; page++ {
+		res, err := c.Search("terms", tsclient.SearchData{Query: "*", SortBy: []string{"num:asc"}, Page: page, PerPage: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Page != page || res.Found != len(terms) {
+			t.Errorf("page %v: got page = %v, found = %v", page, res.Page, res.Found)
+		}
+
+		if len(res.Hits) == 0 {
+			break
+		}
+		ids = append(ids, hitIDs(t, res)...)
+	}
+
+	if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+
+	res, err := c.Search("terms", tsclient.SearchData{Query: "*", SortBy: []string{"num:asc"}, PerPage: 2, Page: 2, LimitHits: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(t, res); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("limit_hits: got %v, want [3]", got)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	c, _ := newTerms(t)
+
+	ok, err := c.Import("terms", "create", []term{{ID: "6", Name: "Fictive"}, terms[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ok, []bool{true, false}) {
+		t.Errorf("import results: got %v, want [true false]", ok)
+	}
+
+	ok, err = c.Import("terms", "upsert", []term{{ID: "1", Name: "Plural"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ok, []bool{true}) {
+		t.Errorf("upsert results: got %v, want [true]", ok)
+	}
+
+	it, err := c.Export("terms", tsclient.ExportData{FilterBy: "num:<=20", IncludeFields: []string{"id", "name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var got []term
+	for it.Next() {
+		var doc term
+		err = it.Decode(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, doc)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// documents are exported in insertion order, and upserting replaces the whole document
+	want := []term{{ID: "1", Name: "Plural"}, {ID: "2", Name: "Plurality"}, {ID: "6", Name: "Fictive"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exported %+v, want %+v", got, want)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	s := tstest.NewServer()
+	defer s.Close()
+
+	_, err := tsclient.New(s.URL, "wrong")
+	if !errors.Is(err, tsclient.ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized", err)
+	}
+}